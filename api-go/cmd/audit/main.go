@@ -14,32 +14,12 @@ import (
 	"unicode"
 
 	"github.com/etymograph/api/internal/config"
+	"github.com/etymograph/api/internal/etymology"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
-type Etymology struct {
-	Definition struct {
-		Brief    string `json:"brief"`
-		Detailed string `json:"detailed"`
-	} `json:"definition"`
-	Origin struct {
-		Language string `json:"language"`
-		Root     string `json:"root"`
-		Meaning  string `json:"meaning"`
-		Components []struct {
-			Part    string `json:"part"`
-			Meaning string `json:"meaning"`
-			Origin  string `json:"origin"`
-		} `json:"components"`
-	} `json:"origin"`
-	Derivatives []struct {
-		Word    string `json:"word"`
-		Meaning string `json:"meaning"`
-	} `json:"derivatives"`
-}
-
 type Issue struct {
 	Word    string
 	ID      int64
@@ -197,8 +177,8 @@ func main() {
 func auditWord(word WordWithEtymology) []Issue {
 	var issues []Issue
 
-	var etym Etymology
-	if err := json.Unmarshal(word.Etymology, &etym); err != nil {
+	doc, err := etymology.DecodeStrict(word.Etymology)
+	if err != nil {
 		issues = append(issues, Issue{
 			Word:    word.Word,
 			ID:      word.ID,
@@ -208,28 +188,31 @@ func auditWord(word WordWithEtymology) []Issue {
 		return issues
 	}
 
+	brief := doc.Brief()
+	root := doc.OriginRoot()
+
 	// Check 1: Circular definition (root equals word)
-	if strings.EqualFold(etym.Origin.Root, word.Word) {
+	if strings.EqualFold(root, word.Word) {
 		issues = append(issues, Issue{
 			Word:    word.Word,
 			ID:      word.ID,
 			Type:    "CIRCULAR_ROOT",
-			Details: fmt.Sprintf("Root '%s' equals word", etym.Origin.Root),
+			Details: fmt.Sprintf("Root '%s' equals word", root),
 		})
 	}
 
 	// Check 2: Brief definition in English (should be Korean for ko language)
-	if etym.Definition.Brief != "" && isEnglishOnly(etym.Definition.Brief) {
+	if brief != "" && isEnglishOnly(brief) {
 		issues = append(issues, Issue{
 			Word:    word.Word,
 			ID:      word.ID,
 			Type:    "ENGLISH_BRIEF",
-			Details: fmt.Sprintf("Brief definition in English: '%s'", etym.Definition.Brief),
+			Details: fmt.Sprintf("Brief definition in English: '%s'", brief),
 		})
 	}
 
 	// Check 3: Empty or missing brief definition
-	if strings.TrimSpace(etym.Definition.Brief) == "" {
+	if strings.TrimSpace(brief) == "" {
 		issues = append(issues, Issue{
 			Word:    word.Word,
 			ID:      word.ID,
@@ -239,7 +222,7 @@ func auditWord(word WordWithEtymology) []Issue {
 	}
 
 	// Check 4: Empty origin root
-	if strings.TrimSpace(etym.Origin.Root) == "" {
+	if strings.TrimSpace(root) == "" {
 		issues = append(issues, Issue{
 			Word:    word.Word,
 			ID:      word.ID,
@@ -248,6 +231,22 @@ func auditWord(word WordWithEtymology) []Issue {
 		})
 	}
 
+	// Check 10: Very short brief (likely incomplete)
+	if len(brief) > 0 && len(brief) < 2 {
+		issues = append(issues, Issue{
+			Word:    word.Word,
+			ID:      word.ID,
+			Type:    "TOO_SHORT_BRIEF",
+			Details: fmt.Sprintf("Brief too short: '%s'", brief),
+		})
+	}
+
+	// Remaining checks only apply to the word shape; affixes have no components or derivatives
+	if doc.Kind != etymology.KindWord {
+		return issues
+	}
+	etym := doc.Word
+
 	// Check 5: Components with empty parts
 	for i, comp := range etym.Origin.Components {
 		if strings.TrimSpace(comp.Part) == "" {
@@ -295,12 +294,12 @@ func auditWord(word WordWithEtymology) []Issue {
 	}
 
 	// Check 8: Origin meaning in English only (should be Korean for ko)
-	if etym.Origin.Meaning != "" && isEnglishOnly(etym.Origin.Meaning) {
+	if etym.Origin.RootMeaning != "" && isEnglishOnly(etym.Origin.RootMeaning) {
 		issues = append(issues, Issue{
 			Word:    word.Word,
 			ID:      word.ID,
 			Type:    "ENGLISH_ORIGIN_MEANING",
-			Details: fmt.Sprintf("Origin meaning in English: '%s'", etym.Origin.Meaning),
+			Details: fmt.Sprintf("Origin meaning in English: '%s'", etym.Origin.RootMeaning),
 		})
 	}
 
@@ -317,16 +316,6 @@ func auditWord(word WordWithEtymology) []Issue {
 		}
 	}
 
-	// Check 10: Very short brief (likely incomplete)
-	if len(etym.Definition.Brief) > 0 && len(etym.Definition.Brief) < 2 {
-		issues = append(issues, Issue{
-			Word:    word.Word,
-			ID:      word.ID,
-			Type:    "TOO_SHORT_BRIEF",
-			Details: fmt.Sprintf("Brief too short: '%s'", etym.Definition.Brief),
-		})
-	}
-
 	return issues
 }
 
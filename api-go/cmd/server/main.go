@@ -9,10 +9,12 @@ import (
 
 	"github.com/etymograph/api/internal/auth"
 	"github.com/etymograph/api/internal/cache"
+	"github.com/etymograph/api/internal/client"
 	"github.com/etymograph/api/internal/config"
 	"github.com/etymograph/api/internal/database"
 	"github.com/etymograph/api/internal/handler"
 	"github.com/etymograph/api/internal/middleware"
+	"github.com/etymograph/api/internal/suggest"
 	"github.com/etymograph/api/internal/validator"
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -37,6 +39,11 @@ func main() {
 		log.Printf("Warning: Failed to migrate etymology to revisions: %v", err)
 	}
 
+	// Populate root/morpheme index for revisions created before it existed
+	if err := database.BackfillEtymologyIndex(db); err != nil {
+		log.Printf("Warning: Failed to backfill etymology index: %v", err)
+	}
+
 	// Create partial index for unfilled words (etymology IS NULL)
 	// This index helps efficiently query words that need etymology to be filled
 	db.Exec(`CREATE INDEX IF NOT EXISTS idx_words_etymology_null
@@ -51,6 +58,29 @@ func main() {
 		// Continue without Redis cache (fail-open)
 	}
 
+	// Word cache: in-process LRU in front of Redis (LRU only without Redis)
+	wordStore := cache.NewTieredStore(cache.NewLRU("word", cfg.CacheL1MaxEntries, cfg.CacheL1MaxBytes, cfg.CacheL1TTL), redisCache)
+	wordStore.Subscribe(context.Background())
+	wordCache := cache.NewWordCache(wordStore, redisCache, cache.WordCacheOptions{
+		KeyVersion:    cfg.CacheKeyVersion,
+		TTL:           cfg.CacheTTL,
+		PreferenceTTL: cfg.CachePreferenceTTL,
+		Jitter:        cfg.CacheTTLJitter,
+		MaxValueBytes: cfg.CacheMaxValueBytes,
+	})
+
+	// Search responses cached under pre-versioning keys never expire; drop them once
+	if redisCache != nil {
+		go func() {
+			deleted, err := wordCache.PurgeLegacyKeys(context.Background())
+			if err != nil {
+				log.Printf("Warning: Failed to purge legacy word cache keys: %v", err)
+			} else if deleted > 0 {
+				log.Printf("Purged %d legacy word cache keys", deleted)
+			}
+		}()
+	}
+
 	// Load words.txt and priority_words.txt into Redis for autocomplete
 	if redisCache != nil {
 		go loadWordsToRedis(redisCache, "data/words.txt")
@@ -64,18 +94,26 @@ func main() {
 		// Continue without validator (fail-open)
 	}
 
+	// Initialize suggestion index (prefix matches and typo corrections)
+	suggester, err := suggest.Load("data/words.txt", "data/priority_words.txt")
+	if err != nil {
+		log.Printf("Warning: Failed to load suggestion index: %v", err)
+		// Continue with Redis prefix suggestions only
+	}
+
 	// Initialize Google OAuth config
 	var googleConfig = auth.NewGoogleOAuthConfig(cfg.GoogleClientID, cfg.GoogleClientSecret, cfg.GoogleRedirectURL)
 
 	// Initialize handlers
-	wordHandler := handler.NewWordHandler(db, redisCache, cfg, wordValidator)
+	wordHandler := handler.NewWordHandler(db, redisCache, wordCache, cfg, wordValidator, suggester)
 	sessionHandler := handler.NewSessionHandler(db)
 	exportHandler := handler.NewExportHandler(db)
 	authHandler := handler.NewAuthHandler(db, cfg.JWTSecret, googleConfig, cfg.FrontendURL)
 	historyHandler := handler.NewHistoryHandler(db, redisCache)
-	fillHandler := handler.NewFillHandler(db, redisCache, cfg.LLMProxyURL)
+	fillHandler := handler.NewFillHandler(db, redisCache, cfg.LLMProxyURL, cfg.LLMTimeout)
 	errorReportHandler := handler.NewErrorReportHandler(db)
-	adminHandler := handler.NewAdminHandler(db)
+	adminHandler := handler.NewAdminHandler(db, wordCache)
+	rootHandler := handler.NewRootHandler(db)
 
 	// Setup router
 	r := gin.Default()
@@ -91,6 +129,7 @@ func main() {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
 		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Header("Access-Control-Expose-Headers", "X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset, Retry-After")
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
 			return
@@ -98,6 +137,17 @@ func main() {
 		c.Next()
 	})
 
+	// Rate limiting via the rate-limiter service (routes mapped in middleware.RouteActions)
+	if rateLimiter := newRateLimiter(cfg); rateLimiter != nil {
+		r.Use(middleware.RateLimitMiddleware(middleware.RateLimitConfig{
+			Client:      rateLimiter,
+			JWTSecret:   cfg.JWTSecret,
+			AdminEmails: cfg.AdminEmails,
+			FailOpen:    cfg.RateLimitFailOpen,
+			Timeout:     cfg.RateLimitTimeout,
+		}))
+	}
+
 	// Health check
 	r.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{"status": "ok"})
@@ -119,11 +169,16 @@ func main() {
 		// Morphemes (for frontend caching)
 		api.GET("/morphemes", wordHandler.GetMorphemes)
 
+		// Words sharing a root or morpheme (from the etymology index)
+		api.GET("/roots/:root/words", rootHandler.GetWordsByRoot)
+		api.GET("/morphemes/:part/words", rootHandler.GetWordsByMorpheme)
+
 		// Words (with optional auth for history tracking)
 		api.GET("/words/suggest", wordHandler.Suggest)
 		api.GET("/words/unfilled", wordHandler.GetUnfilled)
 		api.GET("/words/:word/exists", wordHandler.Exists)
 		api.POST("/words/search", middleware.OptionalAuthMiddleware(cfg.JWTSecret), wordHandler.Search)
+		api.POST("/words/search/stream", middleware.OptionalAuthMiddleware(cfg.JWTSecret), wordHandler.SearchStream)
 		api.GET("/words/:word/etymology", wordHandler.GetEtymology)
 		api.GET("/words/:word/derivatives", wordHandler.GetDerivatives)
 		api.GET("/words/:word/synonyms", wordHandler.GetSynonyms)
@@ -144,6 +199,8 @@ func main() {
 		// Sessions
 		api.POST("/sessions", sessionHandler.Create)
 		api.GET("/sessions/:id", sessionHandler.Get)
+		api.DELETE("/sessions/:id", sessionHandler.Delete)
+		api.GET("/sessions/:id/graph", middleware.OptionalAuthMiddleware(cfg.JWTSecret), sessionHandler.GetGraph)
 		api.POST("/sessions/:id/words", sessionHandler.AddWord)
 		api.DELETE("/sessions/:id/words/:wordId", sessionHandler.RemoveWord)
 
@@ -174,6 +231,7 @@ func main() {
 			adminDashboardGroup.GET("/error-reports", adminHandler.ListErrorReports)
 			adminDashboardGroup.PUT("/error-reports/:id", adminHandler.UpdateErrorReport)
 			adminDashboardGroup.GET("/search-analytics", adminHandler.GetSearchAnalytics)
+			adminDashboardGroup.POST("/cache/purge", adminHandler.PurgeCache)
 		}
 	}
 
@@ -188,6 +246,28 @@ func main() {
 	}
 }
 
+// newRateLimiter picks the rate-limiter transport: gRPC when RATE_LIMIT_GRPC_ADDR is set,
+// with HTTP as the fallback, otherwise HTTP alone. Returns nil when neither is configured.
+func newRateLimiter(cfg *config.Config) client.RateLimiter {
+	var httpClient client.RateLimiter
+	if cfg.RateLimitURL != "" {
+		httpClient = client.NewRateLimitClient(cfg.RateLimitURL, cfg.RateLimitTimeout)
+	}
+	if cfg.RateLimitGRPCAddr == "" {
+		return httpClient
+	}
+
+	grpcClient, err := client.NewGRPCRateLimitClient(cfg.RateLimitGRPCAddr, cfg.RateLimitTimeout)
+	if err != nil {
+		log.Printf("Warning: Failed to create rate limiter gRPC client, using HTTP: %v", err)
+		return httpClient
+	}
+	if httpClient == nil {
+		return grpcClient
+	}
+	return &client.FallbackRateLimiter{Primary: grpcClient, Fallback: httpClient}
+}
+
 // loadWordsToRedis loads words from a file into Redis for autocomplete
 func loadWordsToRedis(redisCache *cache.RedisCache, wordListPath string) {
 	ctx := context.Background()
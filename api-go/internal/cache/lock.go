@@ -0,0 +1,59 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrLockHeld is returned by AcquireLock when the lock has another holder
+var ErrLockHeld = errors.New("lock held by another holder")
+
+// releaseScript deletes a lock only if it still holds the caller's token,
+// so a holder whose lock expired cannot release someone else's
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// Lock is a Redis lock (SET NX) identified by a random token
+type Lock struct {
+	client *redis.Client
+	key    string
+	token  string
+}
+
+// GenerateLockKey generates the lock key for generating a word's first revision
+// Format: "lock:generate:{word}:{language}" (e.g., "lock:generate:teacher:ko")
+func GenerateLockKey(word, language string) string {
+	return "lock:generate:" + CacheKey(word, language)
+}
+
+// AcquireLock takes the lock at key for at most ttl, or returns ErrLockHeld
+func (c *RedisCache) AcquireLock(ctx context.Context, key string, ttl time.Duration) (*Lock, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	token := hex.EncodeToString(buf)
+
+	ok, err := c.client.SetNX(ctx, key, token, ttl).Result()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrLockHeld
+	}
+	return &Lock{client: c.client, key: key, token: token}, nil
+}
+
+// Release deletes the lock if it is still held by this Lock
+func (l *Lock) Release(ctx context.Context) error {
+	return releaseScript.Run(ctx, l.client, []string{l.key}, l.token).Err()
+}
@@ -0,0 +1,111 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// LRU is an in-process cache bounded by entry count and total value size.
+// Entries live for at most its TTL, so copies of shared data stay short-lived.
+type LRU struct {
+	name       string
+	mu         sync.Mutex
+	order      *list.List // front = most recently used
+	items      map[string]*list.Element
+	bytes      int
+	maxEntries int
+	maxBytes   int
+	ttl        time.Duration
+}
+
+type lruEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// NewLRU creates an LRU reported as name in cache_lookups_total.
+// maxEntries or maxBytes <= 0 leaves that bound off.
+func NewLRU(name string, maxEntries, maxBytes int, ttl time.Duration) *LRU {
+	return &LRU{
+		name:       name,
+		order:      list.New(),
+		items:      make(map[string]*list.Element),
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		ttl:        ttl,
+	}
+}
+
+// Get returns the value for key if it is cached and not expired
+func (l *LRU) Get(key string) ([]byte, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	elem, ok := l.items[key]
+	if ok && time.Now().After(elem.Value.(*lruEntry).expiresAt) {
+		l.remove(elem)
+		ok = false
+	}
+	if !ok {
+		recordLookup(l.name, LayerL1, ResultMiss)
+		return nil, false
+	}
+	recordLookup(l.name, LayerL1, ResultHit)
+	l.order.MoveToFront(elem)
+	return elem.Value.(*lruEntry).value, true
+}
+
+// Set caches value for ttl, capped at the LRU's TTL (ttl <= 0 uses it as is).
+// Values larger than the size bound are not cached.
+func (l *LRU) Set(key string, value []byte, ttl time.Duration) {
+	if ttl <= 0 || ttl > l.ttl {
+		ttl = l.ttl
+	}
+	if l.maxBytes > 0 && len(value) > l.maxBytes {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if elem, ok := l.items[key]; ok {
+		l.remove(elem)
+	}
+	l.items[key] = l.order.PushFront(&lruEntry{key: key, value: value, expiresAt: time.Now().Add(ttl)})
+	l.bytes += len(value)
+
+	for (l.maxEntries > 0 && l.order.Len() > l.maxEntries) || (l.maxBytes > 0 && l.bytes > l.maxBytes) {
+		l.remove(l.order.Back())
+	}
+}
+
+// Delete removes keys
+func (l *LRU) Delete(keys ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, key := range keys {
+		if elem, ok := l.items[key]; ok {
+			l.remove(elem)
+		}
+	}
+}
+
+// Flush removes every entry
+func (l *LRU) Flush() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.order.Init()
+	l.items = make(map[string]*list.Element)
+	l.bytes = 0
+}
+
+// remove unlinks elem; callers hold l.mu
+func (l *LRU) remove(elem *list.Element) {
+	entry := l.order.Remove(elem).(*lruEntry)
+	delete(l.items, entry.key)
+	l.bytes -= len(entry.value)
+}
@@ -2,6 +2,7 @@ package cache
 
 import (
 	"context"
+	"errors"
 	"log"
 	"strconv"
 	"strings"
@@ -33,16 +34,21 @@ func NewRedisCache(redisURL string) (*RedisCache, error) {
 	return &RedisCache{client: client}, nil
 }
 
+// Get returns ErrMiss if key does not exist
 func (c *RedisCache) Get(ctx context.Context, key string) ([]byte, error) {
-	return c.client.Get(ctx, key).Bytes()
+	value, err := c.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrMiss
+	}
+	return value, err
 }
 
-func (c *RedisCache) Set(ctx context.Context, key string, value []byte) error {
-	return c.client.Set(ctx, key, value, 0).Err() // TTL 0 = no expiration
+func (c *RedisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.client.Set(ctx, key, value, ttl).Err() // TTL 0 = no expiration
 }
 
-func (c *RedisCache) Delete(ctx context.Context, key string) error {
-	return c.client.Del(ctx, key).Err()
+func (c *RedisCache) Delete(ctx context.Context, keys ...string) error {
+	return c.client.Del(ctx, keys...).Err()
 }
 
 func (c *RedisCache) Close() error {
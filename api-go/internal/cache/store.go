@@ -0,0 +1,47 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// ErrMiss is returned by Store.Get when a key is not cached
+var ErrMiss = errors.New("cache miss")
+
+// Store is a byte-value cache keyed by string
+type Store interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// Flusher is implemented by stores keeping local copies that a purge must drop
+type Flusher interface {
+	Flush(ctx context.Context) error
+}
+
+// Cache layers and lookup results reported in cache_lookups_total
+const (
+	LayerL1 = "l1" // in-process LRU
+	LayerL2 = "l2" // Redis
+
+	ResultHit   = "hit"
+	ResultMiss  = "miss"
+	ResultError = "error"
+)
+
+var cacheLookupsTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "cache_lookups_total",
+		Help: "Cache lookups by cache, layer (l1, l2) and result (hit, miss, error)",
+	},
+	[]string{"cache", "layer", "result"},
+)
+
+func recordLookup(cache, layer, result string) {
+	cacheLookupsTotal.WithLabelValues(cache, layer, result).Inc()
+}
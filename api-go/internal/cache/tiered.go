@@ -0,0 +1,159 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// InvalidationChannel is the Redis pub/sub channel API replicas use to drop
+// each other's L1 entries
+const InvalidationChannel = "cache:invalidate"
+
+// l2RetryInterval is how long reads and writes skip Redis after it fails,
+// so an outage costs one timeout instead of one per request
+const l2RetryInterval = 5 * time.Second
+
+// invalidation is a message on InvalidationChannel
+type invalidation struct {
+	Keys []string `json:"keys,omitempty"`
+	All  bool     `json:"all,omitempty"`
+}
+
+// TieredStore is a Store with an in-process LRU (L1) in front of Redis (L2).
+// Deletes and flushes are fanned out to every replica's L1 over Redis pub/sub;
+// values set by another replica are seen here once the local copy expires.
+// Without Redis, or while it is failing, the L1 alone serves hot keys.
+type TieredStore struct {
+	l1 *LRU
+	l2 *RedisCache // nil: L1 only
+
+	mu          sync.Mutex
+	l2DownUntil time.Time
+}
+
+// NewTieredStore creates a store; l2 may be nil when Redis is unavailable
+func NewTieredStore(l1 *LRU, l2 *RedisCache) *TieredStore {
+	return &TieredStore{l1: l1, l2: l2}
+}
+
+// Subscribe applies invalidations published by every replica (this one included)
+// until ctx is done. Invalidations missed while disconnected are bounded by the L1 TTL.
+func (s *TieredStore) Subscribe(ctx context.Context) {
+	if s.l2 == nil {
+		return
+	}
+
+	pubsub := s.l2.client.Subscribe(ctx, InvalidationChannel)
+	go func() {
+		<-ctx.Done()
+		pubsub.Close()
+	}()
+	go func() {
+		for msg := range pubsub.Channel() {
+			var inv invalidation
+			if err := json.Unmarshal([]byte(msg.Payload), &inv); err != nil {
+				log.Printf("Invalid cache invalidation message: %v", err)
+				continue
+			}
+			if inv.All {
+				s.l1.Flush()
+			} else {
+				s.l1.Delete(inv.Keys...)
+			}
+		}
+	}()
+}
+
+func (s *TieredStore) Get(ctx context.Context, key string) ([]byte, error) {
+	if value, ok := s.l1.Get(key); ok {
+		return value, nil
+	}
+	if !s.l2Available() {
+		return nil, ErrMiss
+	}
+
+	value, err := s.l2.Get(ctx, key)
+	switch {
+	case err == nil:
+		recordLookup(s.l1.name, LayerL2, ResultHit)
+		s.l1.Set(key, value, 0)
+		return value, nil
+	case errors.Is(err, ErrMiss):
+		recordLookup(s.l1.name, LayerL2, ResultMiss)
+		return nil, ErrMiss
+	default:
+		recordLookup(s.l1.name, LayerL2, ResultError)
+		s.l2Failed(err)
+		return nil, err
+	}
+}
+
+// Set writes through to Redis and keeps a local copy for up to the L1 TTL
+func (s *TieredStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	s.l1.Set(key, value, ttl)
+	if !s.l2Available() {
+		return nil
+	}
+	if err := s.l2.Set(ctx, key, value, ttl); err != nil {
+		s.l2Failed(err)
+		return err
+	}
+	return nil
+}
+
+// Delete removes keys from Redis and from every replica's L1.
+// Redis is tried even while it is being skipped, so a recovered Redis holds no stale value.
+func (s *TieredStore) Delete(ctx context.Context, keys ...string) error {
+	s.l1.Delete(keys...)
+	if s.l2 == nil {
+		return nil
+	}
+	if err := s.l2.Delete(ctx, keys...); err != nil {
+		s.l2Failed(err)
+		return err
+	}
+	return s.publish(ctx, invalidation{Keys: keys})
+}
+
+// Flush empties every replica's L1, e.g. after keys were deleted from Redis directly
+func (s *TieredStore) Flush(ctx context.Context) error {
+	s.l1.Flush()
+	if s.l2 == nil {
+		return nil
+	}
+	return s.publish(ctx, invalidation{All: true})
+}
+
+func (s *TieredStore) publish(ctx context.Context, inv invalidation) error {
+	payload, err := json.Marshal(inv)
+	if err != nil {
+		return err
+	}
+	return s.l2.client.Publish(ctx, InvalidationChannel, payload).Err()
+}
+
+func (s *TieredStore) l2Available() bool {
+	if s.l2 == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return time.Now().After(s.l2DownUntil)
+}
+
+// l2Failed skips Redis for l2RetryInterval after an error other than the caller going away
+func (s *TieredStore) l2Failed(err error) {
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if time.Now().After(s.l2DownUntil) {
+		log.Printf("Redis cache error, serving from L1 only for %s: %v", l2RetryInterval, err)
+	}
+	s.l2DownUntil = time.Now().Add(l2RetryInterval)
+}
@@ -0,0 +1,328 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Word cache defaults, used for zero WordCacheOptions fields
+const (
+	DefaultKeyVersion    = "v1"
+	DefaultWordTTL       = 7 * 24 * time.Hour
+	DefaultPreferenceTTL = 24 * time.Hour
+)
+
+// ErrValueTooLarge is returned when a response exceeds WordCacheOptions.MaxValueBytes
+var ErrValueTooLarge = errors.New("cache value too large")
+
+// WordCacheOptions configures the search response cache
+type WordCacheOptions struct {
+	KeyVersion    string        // key prefix segment; bump it to cut over to a new response shape
+	TTL           time.Duration // responses and latest revision numbers
+	PreferenceTTL time.Duration // users' preferred revision numbers
+	Jitter        float64       // fraction of a TTL randomly added or removed, so entries don't expire together
+	MaxValueBytes int           // larger responses are not cached (0 = no limit)
+}
+
+func (o WordCacheOptions) withDefaults() WordCacheOptions {
+	if o.KeyVersion == "" {
+		o.KeyVersion = DefaultKeyVersion
+	}
+	if o.TTL <= 0 {
+		o.TTL = DefaultWordTTL
+	}
+	if o.PreferenceTTL <= 0 {
+		o.PreferenceTTL = DefaultPreferenceTTL
+	}
+	if o.Jitter < 0 {
+		o.Jitter = 0
+	} else if o.Jitter > 0.5 {
+		o.Jitter = 0.5
+	}
+	return o
+}
+
+// ttl applies jitter to base
+func (o WordCacheOptions) ttl(base time.Duration) time.Duration {
+	if o.Jitter == 0 {
+		return base
+	}
+	return base + time.Duration((rand.Float64()*2-1)*o.Jitter*float64(base))
+}
+
+// WordCache caches search responses per revision, latest revision numbers and
+// users' preferred revisions in a Store, under versioned keys
+type WordCache struct {
+	store Store
+	redis *RedisCache // scanned by purges; nil without Redis
+	opts  WordCacheOptions
+}
+
+// NewWordCache creates a word cache over store; redis may be nil.
+// Zero opts fields take the defaults.
+func NewWordCache(store Store, redis *RedisCache, opts WordCacheOptions) *WordCache {
+	return &WordCache{store: store, redis: redis, opts: opts.withDefaults()}
+}
+
+// KeyVersion returns the version segment of the word cache keys in use
+func (w *WordCache) KeyVersion() string {
+	return w.opts.KeyVersion
+}
+
+// wordPrefix is the prefix of every word cache key of a version
+// Format: "cache:{version}:" (e.g., "cache:v1:")
+func wordPrefix(version string) string {
+	return "cache:" + version + ":"
+}
+
+// revisionKey generates the key for one revision's search response
+// Format: "cache:{version}:word:{word}:{language}:r{revision}" (e.g., "cache:v1:word:teacher:ko:r2")
+func (w *WordCache) revisionKey(word, language string, revision int) string {
+	return wordPrefix(w.opts.KeyVersion) + "word:" + CacheKey(word, language) + ":r" + strconv.Itoa(revision)
+}
+
+// latestKey generates the key holding a word's latest revision number
+// Format: "cache:{version}:word:{word}:{language}:latest"
+func (w *WordCache) latestKey(word, language string) string {
+	return wordPrefix(w.opts.KeyVersion) + "word:" + CacheKey(word, language) + ":latest"
+}
+
+// preferenceKey generates the key holding a user's preferred revision number for a word
+// Format: "cache:{version}:pref:{userId}:{word}:{language}" (e.g., "cache:v1:pref:42:teacher:ko")
+func (w *WordCache) preferenceKey(userID int64, word, language string) string {
+	return wordPrefix(w.opts.KeyVersion) + "pref:" + strconv.FormatInt(userID, 10) + ":" + CacheKey(word, language)
+}
+
+// getInt reads an integer value; ok is false on a miss or error
+func (w *WordCache) getInt(ctx context.Context, key string) (int, bool) {
+	value, err := w.store.Get(ctx, key)
+	if err != nil {
+		return 0, false
+	}
+	n, err := strconv.Atoi(string(value))
+	return n, err == nil
+}
+
+func (w *WordCache) setInt(ctx context.Context, key string, n int, ttl time.Duration) error {
+	return w.store.Set(ctx, key, []byte(strconv.Itoa(n)), ttl)
+}
+
+// GetWordResponse returns the cached search response for a revision of a word
+func (w *WordCache) GetWordResponse(ctx context.Context, word, language string, revision int) ([]byte, error) {
+	return w.store.Get(ctx, w.revisionKey(word, language, revision))
+}
+
+// SetWordResponse caches the search response for a revision of a word
+func (w *WordCache) SetWordResponse(ctx context.Context, word, language string, revision int, value []byte) error {
+	if w.opts.MaxValueBytes > 0 && len(value) > w.opts.MaxValueBytes {
+		return ErrValueTooLarge
+	}
+	return w.store.Set(ctx, w.revisionKey(word, language, revision), value, w.opts.ttl(w.opts.TTL))
+}
+
+// GetLatestRevision returns the cached latest revision number of a word
+func (w *WordCache) GetLatestRevision(ctx context.Context, word, language string) (int, bool) {
+	return w.getInt(ctx, w.latestKey(word, language))
+}
+
+// SetLatestRevision caches the latest revision number of a word
+func (w *WordCache) SetLatestRevision(ctx context.Context, word, language string, revision int) error {
+	return w.setInt(ctx, w.latestKey(word, language), revision, w.opts.ttl(w.opts.TTL))
+}
+
+// GetPreference returns a user's cached preferred revision number for a word.
+// 0 means the user has no preference and sees the latest revision.
+func (w *WordCache) GetPreference(ctx context.Context, userID int64, word, language string) (int, bool) {
+	return w.getInt(ctx, w.preferenceKey(userID, word, language))
+}
+
+// SetPreference caches a user's preferred revision number for a word (0 = none)
+func (w *WordCache) SetPreference(ctx context.Context, userID int64, word, language string, revision int) error {
+	return w.setInt(ctx, w.preferenceKey(userID, word, language), revision, w.opts.ttl(w.opts.PreferenceTTL))
+}
+
+// DeletePreference removes a user's cached preferred revision for a word
+func (w *WordCache) DeletePreference(ctx context.Context, userID int64, word, language string) error {
+	return w.store.Delete(ctx, w.preferenceKey(userID, word, language))
+}
+
+// InvalidateWord removes the latest revision pointer and the cached responses of
+// revisions 1..maxRevision, e.g. after a new revision changes every response's revision list
+func (w *WordCache) InvalidateWord(ctx context.Context, word, language string, maxRevision int) error {
+	keys := []string{w.latestKey(word, language)}
+	for rev := 1; rev <= maxRevision; rev++ {
+		keys = append(keys, w.revisionKey(word, language, rev))
+	}
+	return w.store.Delete(ctx, keys...)
+}
+
+// PurgeFilter selects word cache keys to delete. Empty Word and Language match
+// every word and language; an empty Version is the current key version.
+type PurgeFilter struct {
+	Word     string
+	Language string
+	Version  string
+}
+
+// PurgeWordCache deletes the responses, latest revision numbers and preferences
+// matching filter and returns how many keys were deleted from Redis
+func (w *WordCache) PurgeWordCache(ctx context.Context, filter PurgeFilter) (int64, error) {
+	version := filter.Version
+	if version == "" {
+		version = w.opts.KeyVersion
+	}
+	prefix := escapeGlob(wordPrefix(version))
+
+	patterns := []string{prefix + "*"}
+	if filter.Word != "" || filter.Language != "" {
+		wordLang := globOrAny(strings.ToLower(filter.Word)) + ":" + globOrAny(strings.ToLower(filter.Language))
+		patterns = []string{
+			prefix + "word:" + wordLang + ":*",
+			prefix + "pref:*:" + wordLang,
+		}
+	}
+
+	var deleted int64
+	if w.redis != nil {
+		for _, pattern := range patterns {
+			n, err := w.redis.deleteMatching(ctx, pattern)
+			deleted += n
+			if err != nil {
+				return deleted, err
+			}
+		}
+	}
+
+	// Keys deleted from Redis directly may still be held in replicas' L1
+	if flusher, ok := w.store.(Flusher); ok {
+		if err := flusher.Flush(ctx); err != nil {
+			return deleted, err
+		}
+	}
+	return deleted, nil
+}
+
+// legacyWordKeyPattern matches the unversioned "{word}:{language}" keys search responses
+// were cached under before key versions existed
+const legacyWordKeyPattern = "*:[a-z][a-z]"
+
+// legacyPurgedKey marks that PurgeLegacyKeys has completed, so it runs once per Redis
+const legacyPurgedKey = "cache:legacy-word-keys-purged"
+
+// legacySkipPrefixes are namespaces of current keys that PurgeLegacyKeys never touches
+var legacySkipPrefixes = []string{"cache:", "lock:", "history:", "llm-cache:", "usage:", "rate:"}
+
+// PurgeLegacyKeys deletes the search responses cached under unversioned keys, which
+// were stored without a TTL and would otherwise never go away. Only string keys without
+// a TTL outside the namespaces of current keys are deleted. It does nothing once it
+// has completed against this Redis, and returns how many keys were deleted.
+func (w *WordCache) PurgeLegacyKeys(ctx context.Context) (int64, error) {
+	if w.redis == nil {
+		return 0, nil
+	}
+	c := w.redis.client
+	if n, err := c.Exists(ctx, legacyPurgedKey).Result(); err != nil || n > 0 {
+		return 0, err
+	}
+
+	var deleted int64
+	var cursor uint64
+	for {
+		keys, next, err := c.Scan(ctx, cursor, legacyWordKeyPattern, 500).Result()
+		if err != nil {
+			return deleted, err
+		}
+
+		var candidates []string
+		for _, key := range keys {
+			if !hasAnyPrefix(key, legacySkipPrefixes) {
+				candidates = append(candidates, key)
+			}
+		}
+		if len(candidates) > 0 {
+			pipe := c.Pipeline()
+			types := make([]*redis.StatusCmd, len(candidates))
+			ttls := make([]*redis.DurationCmd, len(candidates))
+			for i, key := range candidates {
+				types[i] = pipe.Type(ctx, key)
+				ttls[i] = pipe.TTL(ctx, key)
+			}
+			if _, err := pipe.Exec(ctx); err != nil {
+				return deleted, err
+			}
+
+			var legacy []string
+			for i, key := range candidates {
+				// TTL reports -1 (no expiry) as a -1ns duration
+				if types[i].Val() == "string" && ttls[i].Val() == -1 {
+					legacy = append(legacy, key)
+				}
+			}
+			if len(legacy) > 0 {
+				n, err := c.Unlink(ctx, legacy...).Result()
+				if err != nil {
+					return deleted, err
+				}
+				deleted += n
+			}
+		}
+
+		if cursor = next; cursor == 0 {
+			break
+		}
+	}
+
+	return deleted, c.Set(ctx, legacyPurgedKey, "1", 0).Err()
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// deleteMatching unlinks every key matching pattern, scanning in batches
+func (c *RedisCache) deleteMatching(ctx context.Context, pattern string) (int64, error) {
+	var deleted int64
+	var cursor uint64
+	for {
+		keys, next, err := c.client.Scan(ctx, cursor, pattern, 500).Result()
+		if err != nil {
+			return deleted, err
+		}
+		if len(keys) > 0 {
+			n, err := c.client.Unlink(ctx, keys...).Result()
+			if err != nil {
+				return deleted, err
+			}
+			deleted += n
+		}
+		if cursor = next; cursor == 0 {
+			return deleted, nil
+		}
+	}
+}
+
+// globOrAny escapes s for a SCAN pattern, or matches anything if s is empty
+func globOrAny(s string) string {
+	if s == "" {
+		return "*"
+	}
+	return escapeGlob(s)
+}
+
+var globEscaper = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`)
+
+// escapeGlob escapes the glob metacharacters of a SCAN pattern
+func escapeGlob(s string) string {
+	return globEscaper.Replace(s)
+}
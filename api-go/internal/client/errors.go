@@ -0,0 +1,72 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Error codes llm-proxy sends in its error responses
+const (
+	CodeRateLimited         = "RATE_LIMITED"
+	CodeQuotaExhausted      = "QUOTA_EXHAUSTED"
+	CodeTimeout             = "TIMEOUT"
+	CodeBadOutput           = "BAD_OUTPUT"
+	CodeSchemaViolation     = "SCHEMA_VIOLATION"
+	CodeContentBlocked      = "CONTENT_BLOCKED"
+	CodeProviderUnavailable = "PROVIDER_UNAVAILABLE"
+	CodeBudgetExceeded      = "DAILY_BUDGET_EXCEEDED"
+	CodeCancelled           = "CANCELLED"
+	CodeInvalidRequest      = "INVALID_REQUEST"
+	CodeInternal            = "INTERNAL_ERROR"
+)
+
+// UpstreamError is an error response from llm-proxy
+type UpstreamError struct {
+	Status     int
+	Code       string // one of the Code constants; empty if the body had none
+	Message    string
+	Provider   string
+	RetryAfter time.Duration
+	Body       string
+}
+
+func (e *UpstreamError) Error() string {
+	return fmt.Sprintf("LLM proxy returned status %d: %s", e.Status, e.Body)
+}
+
+// RateLimited reports whether the request may succeed if retried after RetryAfter:
+// provider rate limits and quotas, and llm-proxy's own daily budget
+func (e *UpstreamError) RateLimited() bool {
+	switch e.Code {
+	case CodeRateLimited, CodeQuotaExhausted, CodeBudgetExceeded:
+		return true
+	}
+	return false
+}
+
+// newUpstreamError decodes an llm-proxy error body
+func newUpstreamError(status int, body []byte) *UpstreamError {
+	e := &UpstreamError{Status: status, Body: string(body)}
+	var decoded struct {
+		Error      string `json:"error"`
+		Code       string `json:"code"`
+		Provider   string `json:"provider"`
+		RetryAfter int    `json:"retryAfter"`
+	}
+	if json.Unmarshal(body, &decoded) == nil {
+		e.Code = decoded.Code
+		e.Message = decoded.Error
+		e.Provider = decoded.Provider
+		e.RetryAfter = time.Duration(decoded.RetryAfter) * time.Second
+	}
+	return e
+}
+
+// AsUpstreamError returns the llm-proxy error response in err's chain, if any
+func AsUpstreamError(err error) (*UpstreamError, bool) {
+	var upstream *UpstreamError
+	ok := errors.As(err, &upstream)
+	return upstream, ok
+}
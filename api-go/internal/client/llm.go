@@ -1,25 +1,37 @@
 package client
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/etymograph/api/internal/etymology"
 )
 
+// TimeoutHeader carries the caller's remaining time budget to llm-proxy in milliseconds,
+// so the proxy can stop provider calls the caller would no longer wait for
+const TimeoutHeader = "X-Request-Timeout-Ms"
+
 type LLMClient struct {
 	baseURL    string
 	httpClient *http.Client
+	// timeout bounds each call; a shorter deadline on the caller's context wins
+	timeout time.Duration
 }
 
-func NewLLMClient(baseURL string) *LLMClient {
+func NewLLMClient(baseURL string, timeout time.Duration) *LLMClient {
 	return &LLMClient{
-		baseURL: baseURL,
-		httpClient: &http.Client{
-			Timeout: 120 * time.Second,
-		},
+		baseURL:    baseURL,
+		httpClient: &http.Client{},
+		timeout:    timeout,
 	}
 }
 
@@ -28,51 +40,273 @@ type AnalyzeRequest struct {
 	Language string `json:"language,omitempty"`
 }
 
-func (c *LLMClient) GetEtymology(word string) (map[string]interface{}, error) {
-	return c.GetEtymologyWithLang(word, "Korean")
+// PromptRef identifies the llm-proxy prompt template that produced a response
+type PromptRef struct {
+	ID      string
+	Version int
 }
 
-func (c *LLMClient) GetEtymologyWithLang(word, language string) (map[string]interface{}, error) {
-	return c.callEndpointWithLang("/api/etymology", word, language)
+// promptRef reads the X-Prompt-Id and X-Prompt-Version response headers
+func promptRef(header http.Header) PromptRef {
+	version, _ := strconv.Atoi(header.Get("X-Prompt-Version"))
+	return PromptRef{ID: header.Get("X-Prompt-Id"), Version: version}
 }
 
-func (c *LLMClient) GetDerivatives(word string) (map[string]interface{}, error) {
-	return c.callEndpoint("/api/derivatives", word)
+func (c *LLMClient) GetEtymology(ctx context.Context, word string) (*etymology.Document, PromptRef, error) {
+	return c.GetEtymologyWithLang(ctx, word, "Korean")
+}
+
+// GetEtymologyWithLang fetches an etymology and decodes it into the typed schema,
+// along with the prompt that produced it.
+// Responses that do not match the schema are returned as errors.
+func (c *LLMClient) GetEtymologyWithLang(ctx context.Context, word, language string) (*etymology.Document, PromptRef, error) {
+	body, header, err := c.post(ctx, "/api/etymology", word, language)
+	if err != nil {
+		return nil, PromptRef{}, err
+	}
+
+	doc, err := etymology.DecodeValid(body)
+	if err != nil {
+		return nil, PromptRef{}, fmt.Errorf("LLM proxy returned invalid etymology for %q: %w", word, err)
+	}
+	return doc, promptRef(header), nil
 }
 
-func (c *LLMClient) GetSynonyms(word string) (map[string]interface{}, error) {
-	return c.callEndpoint("/api/synonyms", word)
+type BatchRequest struct {
+	Words    []string `json:"words"`
+	Language string   `json:"language,omitempty"`
+	Pack     bool     `json:"pack,omitempty"`
 }
 
-func (c *LLMClient) callEndpoint(endpoint, word string) (map[string]interface{}, error) {
-	return c.callEndpointWithLang(endpoint, word, "")
+// BatchEtymology is one word's outcome from GetEtymologyBatch: either a document
+// and its prompt, or the error GetEtymologyWithLang would have returned for the word
+type BatchEtymology struct {
+	Word   string
+	Doc    *etymology.Document
+	Prompt PromptRef
+	Err    error
 }
 
-func (c *LLMClient) callEndpointWithLang(endpoint, word, language string) (map[string]interface{}, error) {
-	reqBody, err := json.Marshal(AnalyzeRequest{Word: word, Language: language})
+// GetEtymologyBatch fetches several etymologies in one llm-proxy request, which
+// schedules them against the provider's rate limits. With pack, short words may share
+// a prompt. Results are in the order of words; the error is for the request as a whole.
+// The batch may take as long as its words would one by one.
+func (c *LLMClient) GetEtymologyBatch(ctx context.Context, words []string, language string, pack bool) ([]BatchEtymology, error) {
+	body, _, err := c.postJSON(ctx, c.timeout*time.Duration(len(words)), "/api/etymology/batch", BatchRequest{Words: words, Language: language, Pack: pack})
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := c.httpClient.Post(
-		c.baseURL+endpoint,
-		"application/json",
-		bytes.NewBuffer(reqBody),
-	)
+	var resp struct {
+		Results []struct {
+			Word          string          `json:"word"`
+			Status        int             `json:"status"`
+			Body          json.RawMessage `json:"body"`
+			PromptID      string          `json:"promptId"`
+			PromptVersion int             `json:"promptVersion"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("LLM proxy returned invalid batch response: %w", err)
+	}
+	if len(resp.Results) != len(words) {
+		return nil, fmt.Errorf("LLM proxy returned %d results for %d words", len(resp.Results), len(words))
+	}
+
+	results := make([]BatchEtymology, len(words))
+	for i, r := range resp.Results {
+		results[i] = BatchEtymology{Word: words[i], Prompt: PromptRef{ID: r.PromptID, Version: r.PromptVersion}}
+		if r.Status != http.StatusOK {
+			results[i].Err = newUpstreamError(r.Status, r.Body)
+			continue
+		}
+		results[i].Doc, err = etymology.DecodeValid(r.Body)
+		if err != nil {
+			results[i].Err = fmt.Errorf("LLM proxy returned invalid etymology for %q: %w", words[i], err)
+		}
+	}
+	return results, nil
+}
+
+// StreamEtymologyWithLang fetches an etymology from llm-proxy's streaming endpoint.
+// onChunk receives the raw model output as it is generated; the decoded, validated
+// document and its prompt are returned once the proxy sends its final result.
+func (c *LLMClient) StreamEtymologyWithLang(ctx context.Context, word, language string, onChunk func(text string)) (*etymology.Document, PromptRef, error) {
+	ctx, cancel := withTimeout(ctx, c.timeout)
+	defer cancel()
+
+	req, err := c.newRequest(ctx, "/api/etymology/stream", AnalyzeRequest{Word: word, Language: language})
 	if err != nil {
-		return nil, err
+		return nil, PromptRef{}, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, PromptRef{}, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("LLM proxy returned status %d: %s", resp.StatusCode, string(body))
+		return nil, PromptRef{}, newUpstreamError(resp.StatusCode, body)
+	}
+
+	var doc *etymology.Document
+	err = readEvents(resp.Body, func(event string, data []byte) (bool, error) {
+		switch event {
+		case "chunk":
+			var chunk struct {
+				Text string `json:"text"`
+			}
+			if err := json.Unmarshal(data, &chunk); err != nil {
+				return false, fmt.Errorf("invalid stream chunk: %w", err)
+			}
+			onChunk(chunk.Text)
+			return false, nil
+		case "result":
+			doc, err = etymology.DecodeValid(data)
+			if err != nil {
+				return false, fmt.Errorf("LLM proxy returned invalid etymology for %q: %w", word, err)
+			}
+			return true, nil
+		case "error":
+			// Same error as a non-streaming error response, so callers handle both alike
+			var streamErr struct {
+				Status int `json:"status"`
+			}
+			_ = json.Unmarshal(data, &streamErr)
+			return false, newUpstreamError(streamErr.Status, data)
+		default:
+			return false, nil
+		}
+	})
+	if err != nil {
+		return nil, PromptRef{}, err
+	}
+	if doc == nil {
+		return nil, PromptRef{}, errors.New("LLM proxy stream ended without a result")
+	}
+	return doc, promptRef(resp.Header), nil
+}
+
+// readEvents parses a server-sent event stream, calling handle for each event
+// until it reports done or the stream ends
+func readEvents(r io.Reader, handle func(event string, data []byte) (bool, error)) error {
+	reader := bufio.NewReader(r)
+	event := "message"
+	var data bytes.Buffer
+	for {
+		line, err := reader.ReadString('\n')
+		line = strings.TrimRight(line, "\r\n")
+
+		switch {
+		case line == "" && data.Len() > 0:
+			done, handleErr := handle(event, data.Bytes())
+			if handleErr != nil || done {
+				return handleErr
+			}
+			event = "message"
+			data.Reset()
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (c *LLMClient) GetDerivatives(ctx context.Context, word string) (map[string]interface{}, error) {
+	return c.callEndpoint(ctx, "/api/derivatives", word)
+}
+
+func (c *LLMClient) GetSynonyms(ctx context.Context, word string) (map[string]interface{}, error) {
+	return c.callEndpoint(ctx, "/api/synonyms", word)
+}
+
+func (c *LLMClient) callEndpoint(ctx context.Context, endpoint, word string) (map[string]interface{}, error) {
+	return c.callEndpointWithLang(ctx, endpoint, word, "")
+}
+
+func (c *LLMClient) callEndpointWithLang(ctx context.Context, endpoint, word, language string) (map[string]interface{}, error) {
+	body, _, err := c.post(ctx, endpoint, word, language)
+	if err != nil {
+		return nil, err
 	}
 
 	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+	if err := json.Unmarshal(body, &result); err != nil {
 		return nil, err
 	}
 
 	return result, nil
 }
+
+// withTimeout applies timeout unless ctx already ends sooner
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// newRequest builds a JSON request bound to ctx, passing its deadline on to llm-proxy
+func (c *LLMClient) newRequest(ctx context.Context, endpoint string, body interface{}) (*http.Request, error) {
+	reqBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+endpoint, bytes.NewBuffer(reqBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if deadline, ok := ctx.Deadline(); ok {
+		req.Header.Set(TimeoutHeader, strconv.FormatInt(time.Until(deadline).Milliseconds(), 10))
+	}
+	return req, nil
+}
+
+// post sends an analyze request and returns the raw response body and headers
+func (c *LLMClient) post(ctx context.Context, endpoint, word, language string) ([]byte, http.Header, error) {
+	return c.postJSON(ctx, c.timeout, endpoint, AnalyzeRequest{Word: word, Language: language})
+}
+
+// postJSON sends reqBody, waiting up to timeout, and returns the raw response body and headers
+func (c *LLMClient) postJSON(ctx context.Context, timeout time.Duration, endpoint string, reqBody interface{}) ([]byte, http.Header, error) {
+	ctx, cancel := withTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := c.newRequest(ctx, endpoint, reqBody)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, nil, newUpstreamError(resp.StatusCode, body)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return body, resp.Header, nil
+}
@@ -0,0 +1,78 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// RateLimiter checks a request against the rate-limiter service
+type RateLimiter interface {
+	Check(ctx context.Context, clientID, tier, action string) (*RateLimitResult, error)
+}
+
+// RateLimitClient calls the rate-limiter service's /check endpoint
+type RateLimitClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+func NewRateLimitClient(baseURL string, timeout time.Duration) *RateLimitClient {
+	return &RateLimitClient{
+		baseURL: baseURL,
+		httpClient: &http.Client{
+			Timeout: timeout,
+		},
+	}
+}
+
+type RateLimitCheckRequest struct {
+	ClientID string `json:"client_id"`
+	Action   string `json:"action"`
+	Tier     string `json:"tier,omitempty"`
+}
+
+// RateLimitResult mirrors the rate-limiter's limiter.CheckResult
+type RateLimitResult struct {
+	Allowed   bool  `json:"allowed"`
+	Remaining int64 `json:"remaining"`
+	ResetAt   int64 `json:"reset_at"`
+	Limit     int64 `json:"limit"`
+}
+
+// Check consumes one request of quota for clientID/action under the given policy tier.
+// A denied request is not an error; errors mean the rate-limiter could not be reached or answered badly.
+func (c *RateLimitClient) Check(ctx context.Context, clientID, tier, action string) (*RateLimitResult, error) {
+	reqBody, err := json.Marshal(RateLimitCheckRequest{ClientID: clientID, Action: action, Tier: tier})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/check", bytes.NewBuffer(reqBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusTooManyRequests {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("rate limiter returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result RateLimitResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
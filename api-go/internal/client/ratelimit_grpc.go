@@ -0,0 +1,58 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	rlclient "github.com/epikoding/etymograph/rate-limiter/client"
+	ratelimiterv1 "github.com/epikoding/etymograph/rate-limiter/proto/ratelimiter/v1"
+)
+
+// GRPCRateLimitClient calls the rate-limiter service over gRPC
+type GRPCRateLimitClient struct {
+	client *rlclient.Client
+}
+
+// NewGRPCRateLimitClient dials addr (e.g. "rate-limiter:9090"). Connections are
+// established lazily, so this does not fail when the rate-limiter is not up yet.
+func NewGRPCRateLimitClient(addr string, timeout time.Duration) (*GRPCRateLimitClient, error) {
+	c, err := rlclient.New(addr, rlclient.Options{Timeout: timeout})
+	if err != nil {
+		return nil, err
+	}
+	return &GRPCRateLimitClient{client: c}, nil
+}
+
+// Check consumes one request of quota for clientID/action under the given policy tier.
+// A denied request is not an error; errors mean the rate-limiter could not be reached.
+func (c *GRPCRateLimitClient) Check(ctx context.Context, clientID, tier, action string) (*RateLimitResult, error) {
+	resp, err := c.client.Check(ctx, &ratelimiterv1.CheckRequest{ClientId: clientID, Action: action, Tier: tier})
+	if err != nil {
+		return nil, err
+	}
+	return &RateLimitResult{
+		Allowed:   resp.Allowed,
+		Remaining: resp.Remaining,
+		ResetAt:   resp.ResetAt,
+		Limit:     resp.Limit,
+	}, nil
+}
+
+func (c *GRPCRateLimitClient) Close() error {
+	return c.client.Close()
+}
+
+// FallbackRateLimiter asks Primary first and Fallback when Primary fails.
+// A check whose context is already done is not retried.
+type FallbackRateLimiter struct {
+	Primary  RateLimiter
+	Fallback RateLimiter
+}
+
+func (f *FallbackRateLimiter) Check(ctx context.Context, clientID, tier, action string) (*RateLimitResult, error) {
+	result, err := f.Primary.Check(ctx, clientID, tier, action)
+	if err == nil || ctx.Err() != nil {
+		return result, err
+	}
+	return f.Fallback.Check(ctx, clientID, tier, action)
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/etymograph/api/internal/model"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
 )
 
@@ -33,6 +34,8 @@ func Migrate(db *gorm.DB) error {
 		&model.ErrorReport{},
 		&model.EtymologyRevision{},
 		&model.UserEtymologyPreference{},
+		&model.WordRoot{},
+		&model.WordMorpheme{},
 	)
 	if err != nil {
 		return err
@@ -55,6 +58,22 @@ func Migrate(db *gorm.DB) error {
 	// Index for user_etymology_preferences JOIN queries on revision_id
 	db.Exec("CREATE INDEX IF NOT EXISTS idx_user_etymology_preferences_revision_id ON user_etymology_preferences(revision_id)")
 
+	// Lookup indexes for "words sharing this root/morpheme" queries
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_word_roots_root_word ON word_roots(root, word_id)")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_word_morphemes_part_word ON word_morphemes(part, word_id)")
+
+	// One index row per revision and root/component, so re-indexing a revision is a no-op.
+	// Duplicates left by earlier backfills are removed first; the unique indexes also
+	// cover queries filtering by revision_id only (leftmost column)
+	db.Exec("DELETE FROM word_roots a USING word_roots b WHERE a.revision_id = b.revision_id AND a.root = b.root AND a.id > b.id")
+	db.Exec("DELETE FROM word_morphemes a USING word_morphemes b WHERE a.revision_id = b.revision_id AND a.part = b.part AND a.position = b.position AND a.id > b.id")
+	db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_word_roots_revision_root ON word_roots(revision_id, root)")
+	db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_word_morphemes_revision_part_position ON word_morphemes(revision_id, part, position)")
+
+	// Drop redundant single-column indexes (covered by the unique indexes above)
+	db.Exec("DROP INDEX IF EXISTS idx_word_roots_revision_id")
+	db.Exec("DROP INDEX IF EXISTS idx_word_morphemes_revision_id")
+
 	return nil
 }
 
@@ -104,3 +123,52 @@ func MigrateEtymologyToRevisions(db *gorm.DB) error {
 	log.Printf("Migrated %d etymology records to etymology_revisions", result.RowsAffected)
 	return nil
 }
+
+// backfillBatchSize is how many revisions BackfillEtymologyIndex decodes at a time
+const backfillBatchSize = 500
+
+// BackfillEtymologyIndex indexes the roots and morphemes of revisions that have no
+// index rows yet, e.g. those created before the index existed. New revisions are
+// indexed by EtymologyRevision.AfterCreate; both go through IndexEntries, so they
+// decode documents (including legacy language-keyed ones) the same way.
+// Safe to run on every start.
+func BackfillEtymologyIndex(db *gorm.DB) error {
+	var roots, morphemes int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var revisions []model.EtymologyRevision
+		return tx.Where("NOT EXISTS (SELECT 1 FROM word_roots wr WHERE wr.revision_id = etymology_revisions.id)").
+			Where("NOT EXISTS (SELECT 1 FROM word_morphemes wm WHERE wm.revision_id = etymology_revisions.id)").
+			FindInBatches(&revisions, backfillBatchSize, func(batch *gorm.DB, _ int) error {
+				var rootRows []model.WordRoot
+				var morphemeRows []model.WordMorpheme
+				for i := range revisions {
+					r, m := revisions[i].IndexEntries()
+					rootRows = append(rootRows, r...)
+					morphemeRows = append(morphemeRows, m...)
+				}
+				if len(rootRows) > 0 {
+					result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&rootRows)
+					if result.Error != nil {
+						return result.Error
+					}
+					roots += result.RowsAffected
+				}
+				if len(morphemeRows) > 0 {
+					result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&morphemeRows)
+					if result.Error != nil {
+						return result.Error
+					}
+					morphemes += result.RowsAffected
+				}
+				return nil
+			}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	if roots > 0 || morphemes > 0 {
+		log.Printf("Backfilled etymology index: %d roots, %d morphemes", roots, morphemes)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/etymograph/api/internal/model"
+	"github.com/glebarez/sqlite"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("open test db: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("open test db: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.AutoMigrate(&model.Word{}, &model.EtymologyRevision{}, &model.WordRoot{}, &model.WordMorpheme{}); err != nil {
+		t.Fatalf("migrate test db: %v", err)
+	}
+	db.Exec("CREATE UNIQUE INDEX idx_word_roots_revision_root ON word_roots(revision_id, root)")
+	db.Exec("CREATE UNIQUE INDEX idx_word_morphemes_revision_part_position ON word_morphemes(revision_id, part, position)")
+	return db
+}
+
+func TestBackfillEtymologyIndexUnwrapsLegacyRows(t *testing.T) {
+	db := newTestDB(t)
+
+	docs := map[string]string{
+		"view":      `{"word": "view", "definition": {"brief": "보다"}, "origin": {"language": "Latin", "root": "Videre", "components": [{"part": "vid", "meaning": "see"}]}}`,
+		"interview": `{"ko": {"word": "interview", "definition": {"brief": "면접"}, "origin": {"language": "Latin", "root": "videre ", "components": [{"part": "inter", "meaning": "between"}, {"part": "vid", "meaning": "see"}]}}}`,
+	}
+	for w, doc := range docs {
+		word := model.Word{Word: w, Language: "ko"}
+		if err := db.Create(&word).Error; err != nil {
+			t.Fatalf("seed word: %v", err)
+		}
+		// Stored as before the index existed, so AfterCreate does not index it
+		rev := model.EtymologyRevision{WordID: word.ID, RevisionNumber: 1, Etymology: datatypes.JSON(doc), CreatedAt: time.Now()}
+		if err := db.Session(&gorm.Session{SkipHooks: true}).Create(&rev).Error; err != nil {
+			t.Fatalf("seed revision: %v", err)
+		}
+	}
+
+	for run := 1; run <= 2; run++ {
+		if err := BackfillEtymologyIndex(db); err != nil {
+			t.Fatalf("run %d: BackfillEtymologyIndex: %v", run, err)
+		}
+
+		var roots int64
+		db.Model(&model.WordRoot{}).Where("root = ?", "videre").Count(&roots)
+		if roots != 2 {
+			t.Errorf("run %d: %d revisions indexed under root videre, want 2", run, roots)
+		}
+		var morphemes int64
+		db.Model(&model.WordMorpheme{}).Count(&morphemes)
+		if morphemes != 3 {
+			t.Errorf("run %d: %d morphemes indexed, want 3", run, morphemes)
+		}
+	}
+}
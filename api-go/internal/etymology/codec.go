@@ -0,0 +1,237 @@
+package etymology
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrEmptyDocument is returned when decoding an empty or null etymology
+var ErrEmptyDocument = errors.New("etymology: empty document")
+
+// ValidationError lists every schema violation found in a document
+type ValidationError struct {
+	Violations []string
+}
+
+func (e *ValidationError) Error() string {
+	return "etymology: invalid document: " + strings.Join(e.Violations, "; ")
+}
+
+// Decode parses an etymology JSON document.
+// The shape is chosen by the "type" field ("suffix", "prefix", or absent for words).
+// Fields these types do not know are ignored, so rows written by older prompts and
+// provider output with extra keys still decode.
+func Decode(data []byte) (*Document, error) {
+	return decode(data, false)
+}
+
+// DecodeStrict is Decode that rejects unknown fields, so drift between the prompts and
+// these types surfaces as an error instead of silently dropped values. It is meant for
+// auditing stored documents; everything that serves or stores them uses Decode.
+func DecodeStrict(data []byte) (*Document, error) {
+	return decode(data, true)
+}
+
+func decode(data []byte, strict bool) (*Document, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("etymology: invalid JSON: %w", err)
+	}
+	if len(fields) == 0 {
+		return nil, ErrEmptyDocument
+	}
+
+	// Legacy rows nest the document under a language key (e.g. {"ko": {...}})
+	if _, ok := fields["word"]; !ok && len(fields) == 1 {
+		for _, inner := range fields {
+			if bytes.HasPrefix(bytes.TrimSpace(inner), []byte("{")) {
+				return decode(inner, strict)
+			}
+		}
+	}
+
+	var kind string
+	if raw, ok := fields["type"]; ok {
+		if err := json.Unmarshal(raw, &kind); err != nil {
+			return nil, fmt.Errorf("etymology: invalid type field: %w", err)
+		}
+	}
+
+	doc := &Document{}
+	var err error
+	switch Kind(kind) {
+	case "", KindWord:
+		doc.Kind = KindWord
+		doc.Word = &WordEtymology{}
+		err = decodeOne(data, doc.Word, strict)
+	case KindSuffix:
+		doc.Kind = KindSuffix
+		doc.Suffix = &SuffixEtymology{}
+		err = decodeOne(data, doc.Suffix, strict)
+	case KindPrefix:
+		doc.Kind = KindPrefix
+		doc.Prefix = &PrefixEtymology{}
+		err = decodeOne(data, doc.Prefix, strict)
+	default:
+		return nil, fmt.Errorf("etymology: unknown type %q", kind)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("etymology: decode %s: %w", doc.Kind, err)
+	}
+
+	return doc, nil
+}
+
+// DecodeValid decodes and validates a document in one step
+func DecodeValid(data []byte) (*Document, error) {
+	doc, err := Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := Validate(doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// Encode validates a document and marshals it back to JSON
+func Encode(doc *Document) ([]byte, error) {
+	if err := Validate(doc); err != nil {
+		return nil, err
+	}
+	return json.Marshal(doc)
+}
+
+// Validate checks the fields every consumer relies on.
+// It returns a *ValidationError listing all violations, or nil.
+func Validate(doc *Document) error {
+	if doc == nil {
+		return ErrEmptyDocument
+	}
+
+	var v []string
+	require := func(value, field string) {
+		if strings.TrimSpace(value) == "" {
+			v = append(v, field+" is required")
+		}
+	}
+
+	switch doc.Kind {
+	case KindWord:
+		if doc.Word == nil {
+			return ErrEmptyDocument
+		}
+		e := doc.Word
+		require(e.Word, "word")
+		require(e.Definition.Brief, "definition.brief")
+		require(e.Origin.Language, "origin.language")
+		require(e.Origin.Root, "origin.root")
+		for i, comp := range e.Origin.Components {
+			require(comp.Part, fmt.Sprintf("origin.components[%d].part", i))
+		}
+		for i, deriv := range e.Derivatives {
+			require(deriv.Word, fmt.Sprintf("derivatives[%d].word", i))
+		}
+		for i, syn := range e.Synonyms {
+			require(syn.Word, fmt.Sprintf("synonyms[%d].word", i))
+		}
+	case KindSuffix:
+		if doc.Suffix == nil {
+			return ErrEmptyDocument
+		}
+		e := doc.Suffix
+		require(e.Word, "word")
+		if e.Type != string(KindSuffix) {
+			v = append(v, fmt.Sprintf("type must be %q", KindSuffix))
+		}
+		require(e.Definition.Brief, "definition.brief")
+		require(e.Origin.Language, "origin.language")
+		require(e.Origin.OriginalForm, "origin.originalForm")
+	case KindPrefix:
+		if doc.Prefix == nil {
+			return ErrEmptyDocument
+		}
+		e := doc.Prefix
+		require(e.Word, "word")
+		if e.Type != string(KindPrefix) {
+			v = append(v, fmt.Sprintf("type must be %q", KindPrefix))
+		}
+		require(e.Definition.Brief, "definition.brief")
+		require(e.Origin.Language, "origin.language")
+		require(e.Origin.OriginalForm, "origin.originalForm")
+	default:
+		return fmt.Errorf("etymology: unknown kind %q", doc.Kind)
+	}
+
+	if len(v) > 0 {
+		return &ValidationError{Violations: v}
+	}
+	return nil
+}
+
+// MarshalJSON writes the active shape without any wrapper
+func (d Document) MarshalJSON() ([]byte, error) {
+	switch d.Kind {
+	case KindSuffix:
+		return json.Marshal(d.Suffix)
+	case KindPrefix:
+		return json.Marshal(d.Prefix)
+	default:
+		return json.Marshal(d.Word)
+	}
+}
+
+// UnmarshalJSON decodes via Decode so embedded documents get the same shape handling
+func (d *Document) UnmarshalJSON(data []byte) error {
+	doc, err := Decode(data)
+	if err != nil {
+		return err
+	}
+	*d = *doc
+	return nil
+}
+
+// UnmarshalJSON accepts both the {"path", "explanation"} object and the legacy plain string.
+// Unknown fields are ignored even under DecodeStrict, which cannot reach custom unmarshalers.
+func (e *Evolution) UnmarshalJSON(data []byte) error {
+	trimmed := bytes.TrimSpace(data)
+	if bytes.Equal(trimmed, []byte("null")) {
+		return nil
+	}
+	if len(trimmed) > 0 && trimmed[0] == '"' {
+		var s string
+		if err := json.Unmarshal(trimmed, &s); err != nil {
+			return err
+		}
+		*e = Evolution{Explanation: s}
+		return nil
+	}
+
+	type evolution Evolution
+	var out evolution
+	if err := json.Unmarshal(trimmed, &out); err != nil {
+		return err
+	}
+	*e = Evolution(out)
+	return nil
+}
+
+// decodeOne decodes exactly one JSON value, rejecting trailing data,
+// and unknown fields as well when strict
+func decodeOne(data []byte, v interface{}, strict bool) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if strict {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("unexpected data after JSON value")
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package etymology
+
+import "testing"
+
+// legacyRow is shaped like revisions stored before the typed schema: nested under a
+// language key, with a plain-string evolution and fields the schema no longer has
+const legacyRow = `{"ko": {
+	"word": "teacher",
+	"definition": {"brief": "선생님"},
+	"origin": {
+		"language": "Old English",
+		"root": "tæcan",
+		"meaning": "to show",
+		"components": [{"part": "teach", "meaning": "to show", "origin": "Old English"}]
+	},
+	"evolution": "tæcan > teche > teach + -er",
+	"derivatives": [{"word": "teaching", "meaning": "가르침"}]
+}}`
+
+func TestDecodeLegacyRow(t *testing.T) {
+	doc, err := Decode([]byte(legacyRow))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if err := Validate(doc); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+	if doc.Headword() != "teacher" || doc.Brief() != "선생님" || doc.OriginRoot() != "tæcan" {
+		t.Errorf("decoded %q, %q, %q", doc.Headword(), doc.Brief(), doc.OriginRoot())
+	}
+	if got := doc.Word.Origin.Components; len(got) != 1 || got[0].Part != "teach" {
+		t.Errorf("components = %+v", got)
+	}
+	if got := doc.Word.Evolution.Explanation; got != "tæcan > teche > teach + -er" {
+		t.Errorf("evolution = %q", got)
+	}
+	if got := doc.Derivatives(); len(got) != 1 || got[0].Word != "teaching" {
+		t.Errorf("derivatives = %+v", got)
+	}
+
+	if _, err := DecodeStrict([]byte(legacyRow)); err == nil {
+		t.Error("DecodeStrict accepted unknown fields")
+	}
+}
+
+func TestDecodeRejectsTrailingData(t *testing.T) {
+	if _, err := Decode([]byte(`{"word": "teacher"} {"word": "student"}`)); err == nil {
+		t.Error("Decode accepted two documents")
+	}
+}
@@ -0,0 +1,214 @@
+package etymology
+
+// Kind identifies which prompt shape an etymology document follows
+type Kind string
+
+const (
+	KindWord   Kind = "word"
+	KindSuffix Kind = "suffix"
+	KindPrefix Kind = "prefix"
+)
+
+// Document is a decoded etymology revision. Exactly one of Word, Suffix or
+// Prefix is set, matching Kind.
+type Document struct {
+	Kind   Kind
+	Word   *WordEtymology
+	Suffix *SuffixEtymology
+	Prefix *PrefixEtymology
+}
+
+// =============================================================================
+// Word shape (EtymologyPrompt)
+// =============================================================================
+
+// WordEtymology is the response shape of llm-proxy's EtymologyPrompt
+type WordEtymology struct {
+	Word                     string       `json:"word"`
+	Definition               Definition   `json:"definition"`
+	Examples                 []Example    `json:"examples,omitempty"`
+	Origin                   Origin       `json:"origin"`
+	Evolution                Evolution    `json:"evolution"`
+	HistoricalContext        string       `json:"historicalContext,omitempty"`
+	OriginalMeaning          string       `json:"originalMeaning,omitempty"`
+	OriginalMeaningLocalized string       `json:"originalMeaningLocalized,omitempty"`
+	OriginalMeaningKo        string       `json:"originalMeaningKo,omitempty"` // deprecated, use OriginalMeaningLocalized
+	ModernMeaning            string       `json:"modernMeaning,omitempty"`
+	ModernMeaningLocalized   string       `json:"modernMeaningLocalized,omitempty"`
+	ModernMeaningKo          string       `json:"modernMeaningKo,omitempty"` // deprecated, use ModernMeaningLocalized
+	Derivatives              []Derivative `json:"derivatives,omitempty"`
+	Synonyms                 []Synonym    `json:"synonyms,omitempty"`
+	Senses                   []Sense      `json:"senses,omitempty"`
+}
+
+type Definition struct {
+	Brief    string `json:"brief"`
+	Detailed string `json:"detailed,omitempty"`
+	Nuance   string `json:"nuance,omitempty"`
+}
+
+type Example struct {
+	English     string `json:"english"`
+	Translation string `json:"translation"`
+}
+
+type Origin struct {
+	Language    string      `json:"language"`
+	Root        string      `json:"root"`
+	RootMeaning string      `json:"rootMeaning,omitempty"`
+	Components  []Component `json:"components,omitempty"`
+}
+
+type Component struct {
+	Part             string `json:"part"`
+	Meaning          string `json:"meaning"`
+	MeaningLocalized string `json:"meaningLocalized,omitempty"`
+	MeaningKo        string `json:"meaningKo,omitempty"` // deprecated, use MeaningLocalized
+}
+
+// Evolution describes how a word changed over time.
+// Older revisions store it as a plain string, which is decoded into Explanation.
+type Evolution struct {
+	Path        string `json:"path,omitempty"`
+	Explanation string `json:"explanation,omitempty"`
+}
+
+type Derivative struct {
+	Word    string `json:"word"`
+	Meaning string `json:"meaning"`
+}
+
+type Synonym struct {
+	Word    string `json:"word"`
+	Meaning string `json:"meaning"`
+	Nuance  string `json:"nuance,omitempty"`
+}
+
+type Sense struct {
+	Meaning               string   `json:"meaning"`
+	English               string   `json:"english"`
+	Domain                string   `json:"domain,omitempty"`
+	MetaphoricalExtension string   `json:"metaphoricalExtension,omitempty"`
+	Example               *Example `json:"example,omitempty"`
+}
+
+// =============================================================================
+// Affix shapes (SuffixEtymologyPrompt, PrefixEtymologyPrompt)
+// =============================================================================
+
+// SuffixEtymology is the response shape of llm-proxy's SuffixEtymologyPrompt
+type SuffixEtymology struct {
+	Word              string           `json:"word"`
+	Type              string           `json:"type"`
+	Definition        SuffixDefinition `json:"definition"`
+	Origin            AffixOrigin      `json:"origin"`
+	Examples          []AffixExample   `json:"examples,omitempty"`
+	RelatedSuffixes   []RelatedSuffix  `json:"relatedSuffixes,omitempty"`
+	HistoricalContext string           `json:"historicalContext,omitempty"`
+}
+
+type SuffixDefinition struct {
+	Brief               string `json:"brief"`
+	Detailed            string `json:"detailed,omitempty"`
+	GrammaticalFunction string `json:"grammaticalFunction,omitempty"`
+}
+
+type RelatedSuffix struct {
+	Suffix     string `json:"suffix"`
+	Difference string `json:"difference"`
+}
+
+// PrefixEtymology is the response shape of llm-proxy's PrefixEtymologyPrompt
+type PrefixEtymology struct {
+	Word              string           `json:"word"`
+	Type              string           `json:"type"`
+	Definition        PrefixDefinition `json:"definition"`
+	Origin            AffixOrigin      `json:"origin"`
+	Examples          []AffixExample   `json:"examples,omitempty"`
+	RelatedPrefixes   []RelatedPrefix  `json:"relatedPrefixes,omitempty"`
+	HistoricalContext string           `json:"historicalContext,omitempty"`
+}
+
+type PrefixDefinition struct {
+	Brief          string `json:"brief"`
+	Detailed       string `json:"detailed,omitempty"`
+	SemanticEffect string `json:"semanticEffect,omitempty"`
+}
+
+type RelatedPrefix struct {
+	Prefix     string `json:"prefix"`
+	Difference string `json:"difference"`
+}
+
+type AffixOrigin struct {
+	Language        string `json:"language"`
+	OriginalForm    string `json:"originalForm"`
+	OriginalMeaning string `json:"originalMeaning,omitempty"`
+}
+
+type AffixExample struct {
+	Word        string `json:"word"`
+	Base        string `json:"base,omitempty"`
+	Meaning     string `json:"meaning,omitempty"`
+	Explanation string `json:"explanation,omitempty"`
+}
+
+// =============================================================================
+// Accessors shared by all shapes
+// =============================================================================
+
+// Headword returns the analyzed word, suffix or prefix
+func (d *Document) Headword() string {
+	switch d.Kind {
+	case KindSuffix:
+		return d.Suffix.Word
+	case KindPrefix:
+		return d.Prefix.Word
+	default:
+		return d.Word.Word
+	}
+}
+
+// Brief returns the short translated definition
+func (d *Document) Brief() string {
+	switch d.Kind {
+	case KindSuffix:
+		return d.Suffix.Definition.Brief
+	case KindPrefix:
+		return d.Prefix.Definition.Brief
+	default:
+		return d.Word.Definition.Brief
+	}
+}
+
+// OriginLanguage returns the source language of the word or affix
+func (d *Document) OriginLanguage() string {
+	switch d.Kind {
+	case KindSuffix:
+		return d.Suffix.Origin.Language
+	case KindPrefix:
+		return d.Prefix.Origin.Language
+	default:
+		return d.Word.Origin.Language
+	}
+}
+
+// OriginRoot returns the root for words and the original form for affixes
+func (d *Document) OriginRoot() string {
+	switch d.Kind {
+	case KindSuffix:
+		return d.Suffix.Origin.OriginalForm
+	case KindPrefix:
+		return d.Prefix.Origin.OriginalForm
+	default:
+		return d.Word.Origin.Root
+	}
+}
+
+// Derivatives returns the derivative list (only words have derivatives)
+func (d *Document) Derivatives() []Derivative {
+	if d.Kind != KindWord || d.Word.Derivatives == nil {
+		return []Derivative{}
+	}
+	return d.Word.Derivatives
+}
@@ -2,31 +2,23 @@ package filter
 
 import (
 	"strings"
+
+	"github.com/etymograph/api/internal/etymology"
 )
 
 // FilterDerivatives removes grammatical variations of the input word from derivatives.
 // For example, "interest" should not have "interested", "interesting", "interestingly" as derivatives.
-func FilterDerivatives(word string, derivatives []interface{}) []interface{} {
+func FilterDerivatives(word string, derivatives []etymology.Derivative) []etymology.Derivative {
 	if len(derivatives) == 0 {
-		return []interface{}{}
+		return []etymology.Derivative{}
 	}
 
 	word = strings.ToLower(word)
 	variations := generateVariations(word)
 
-	filtered := make([]interface{}, 0)
+	filtered := make([]etymology.Derivative, 0)
 	for _, d := range derivatives {
-		derivMap, ok := d.(map[string]interface{})
-		if !ok {
-			continue
-		}
-
-		derivWord, ok := derivMap["word"].(string)
-		if !ok {
-			continue
-		}
-
-		derivWordLower := strings.ToLower(derivWord)
+		derivWordLower := strings.ToLower(d.Word)
 		if !isVariation(derivWordLower, variations, word) {
 			filtered = append(filtered, d)
 		}
@@ -0,0 +1,255 @@
+package graph
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/etymograph/api/internal/etymology"
+	"github.com/etymograph/api/internal/model"
+)
+
+// Node types (see README "그래프 노드 타입")
+const (
+	NodeWord       = "word"
+	NodeRoot       = "root"
+	NodeComponent  = "component"
+	NodeDerivative = "derivative"
+)
+
+// Edge relations (see README "그래프 엣지")
+const (
+	RelationOrigin     = "origin"     // word → root
+	RelationComponent  = "component"  // root → component, or word → component when there is no root
+	RelationDerivative = "derivative" // component → derivative, or word → derivative when no component matches
+	RelationExplored   = "explored"   // parent word → child word (session ParentID chain)
+)
+
+// Node is a graph vertex. IDs are "<type>:<label>" so the same root or
+// component reached from different words collapses into one node.
+type Node struct {
+	ID        string              `json:"id"`
+	Type      string              `json:"type"`
+	Word      string              `json:"word"`
+	Meaning   string              `json:"meaning,omitempty"`
+	Language  string              `json:"language,omitempty"`
+	Order     int                 `json:"order,omitempty"`
+	Revision  int                 `json:"revision,omitempty"`
+	Etymology *etymology.Document `json:"etymology,omitempty"`
+}
+
+type Edge struct {
+	Source   string `json:"source"`
+	Target   string `json:"target"`
+	Relation string `json:"relation"`
+}
+
+type Graph struct {
+	Nodes []Node `json:"nodes"`
+	Edges []Edge `json:"edges"`
+}
+
+// Entry is one session word with the revision chosen for it
+type Entry struct {
+	SessionWordID int64
+	ParentID      *int64
+	Word          string
+	Order         int
+	Revision      int
+	Etymology     *etymology.Document
+}
+
+type builder struct {
+	graph     Graph
+	nodeIndex map[string]int
+	edgeSeen  map[string]bool
+}
+
+// Build creates the word → root → component → derivative graph for session entries.
+// Output order is deterministic: entries are processed by Order, then by the order
+// of roots, components and derivatives within each etymology.
+func Build(entries []Entry) *Graph {
+	sorted := make([]Entry, len(entries))
+	copy(sorted, entries)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Order < sorted[j].Order })
+
+	b := &builder{
+		graph:     Graph{Nodes: []Node{}, Edges: []Edge{}},
+		nodeIndex: make(map[string]int),
+		edgeSeen:  make(map[string]bool),
+	}
+
+	// Session words first so derivatives that were explored link to the word node
+	sessionNodes := make(map[int64]string)
+	for _, e := range sorted {
+		word := model.NormalizeMorpheme(e.Word)
+		id := nodeID(NodeWord, word)
+		b.addNode(Node{
+			ID:        id,
+			Type:      NodeWord,
+			Word:      word,
+			Order:     e.Order,
+			Revision:  e.Revision,
+			Etymology: e.Etymology,
+		})
+		sessionNodes[e.SessionWordID] = id
+	}
+
+	for _, e := range sorted {
+		wordID := sessionNodes[e.SessionWordID]
+		if e.Etymology != nil {
+			switch e.Etymology.Kind {
+			case etymology.KindWord:
+				b.addWordEtymology(wordID, e.Etymology.Word)
+			case etymology.KindSuffix:
+				b.addAffixEtymology(wordID, e.Etymology.Suffix.Origin, e.Etymology.Suffix.Examples)
+			case etymology.KindPrefix:
+				b.addAffixEtymology(wordID, e.Etymology.Prefix.Origin, e.Etymology.Prefix.Examples)
+			}
+		}
+
+		if e.ParentID != nil {
+			if parentID, ok := sessionNodes[*e.ParentID]; ok && parentID != wordID {
+				b.addEdge(parentID, wordID, RelationExplored)
+			}
+		}
+	}
+
+	return &b.graph
+}
+
+func (b *builder) addWordEtymology(wordID string, etym *etymology.WordEtymology) {
+	parentID := wordID
+	if root := model.NormalizeMorpheme(etym.Origin.Root); root != "" {
+		rootID := nodeID(NodeRoot, root)
+		b.addNode(Node{
+			ID:       rootID,
+			Type:     NodeRoot,
+			Word:     root,
+			Meaning:  etym.Origin.RootMeaning,
+			Language: etym.Origin.Language,
+		})
+		b.addEdge(wordID, rootID, RelationOrigin)
+		parentID = rootID
+	}
+
+	var components []component
+	for _, comp := range etym.Origin.Components {
+		part := model.NormalizeMorpheme(comp.Part)
+		if part == "" {
+			continue
+		}
+		meaning := comp.MeaningLocalized
+		if meaning == "" {
+			meaning = comp.Meaning
+		}
+		compID := nodeID(NodeComponent, part)
+		b.addNode(Node{ID: compID, Type: NodeComponent, Word: part, Meaning: meaning})
+		b.addEdge(parentID, compID, RelationComponent)
+		components = append(components, component{id: compID, part: part})
+	}
+
+	self := b.graph.Nodes[b.nodeIndex[wordID]].Word
+	for _, deriv := range etym.Derivatives {
+		word := model.NormalizeMorpheme(deriv.Word)
+		if word == "" || word == self {
+			continue
+		}
+		derivID := b.derivativeNode(word, deriv.Meaning)
+
+		// Link from the component the derivative visibly shares (longest match), else from the word
+		source := wordID
+		best := 0
+		for _, comp := range components {
+			if n := comp.matchLen(word); n > best {
+				source, best = comp.id, n
+			}
+		}
+		b.addEdge(source, derivID, RelationDerivative)
+	}
+}
+
+func (b *builder) addAffixEtymology(wordID string, origin etymology.AffixOrigin, examples []etymology.AffixExample) {
+	if form := model.NormalizeMorpheme(origin.OriginalForm); form != "" {
+		rootID := nodeID(NodeRoot, form)
+		b.addNode(Node{
+			ID:       rootID,
+			Type:     NodeRoot,
+			Word:     form,
+			Meaning:  origin.OriginalMeaning,
+			Language: origin.Language,
+		})
+		b.addEdge(wordID, rootID, RelationOrigin)
+	}
+
+	for _, ex := range examples {
+		word := model.NormalizeMorpheme(ex.Word)
+		if word == "" {
+			continue
+		}
+		b.addEdge(wordID, b.derivativeNode(word, ex.Meaning), RelationDerivative)
+	}
+}
+
+type component struct {
+	id   string
+	part string
+}
+
+// matchLen returns the stem length if word contains the component in its position
+// ("pre-" as a prefix, "-tion" as a suffix, "view" anywhere), or 0 otherwise
+func (c component) matchLen(word string) int {
+	stem := strings.Trim(c.part, "-")
+	if stem == "" {
+		return 0
+	}
+	var ok bool
+	switch {
+	case strings.HasSuffix(c.part, "-") && !strings.HasPrefix(c.part, "-"):
+		ok = strings.HasPrefix(word, stem)
+	case strings.HasPrefix(c.part, "-") && !strings.HasSuffix(c.part, "-"):
+		ok = strings.HasSuffix(word, stem)
+	default:
+		ok = strings.Contains(word, stem)
+	}
+	if !ok {
+		return 0
+	}
+	return len(stem)
+}
+
+// derivativeNode returns the node for a derivative, reusing the word node if it is in the session
+func (b *builder) derivativeNode(word, meaning string) string {
+	if id := nodeID(NodeWord, word); b.hasNode(id) {
+		return id
+	}
+	id := nodeID(NodeDerivative, word)
+	b.addNode(Node{ID: id, Type: NodeDerivative, Word: word, Meaning: meaning})
+	return id
+}
+
+func (b *builder) hasNode(id string) bool {
+	_, ok := b.nodeIndex[id]
+	return ok
+}
+
+// addNode adds a node unless one with the same ID exists (first occurrence wins)
+func (b *builder) addNode(n Node) {
+	if b.hasNode(n.ID) {
+		return
+	}
+	b.nodeIndex[n.ID] = len(b.graph.Nodes)
+	b.graph.Nodes = append(b.graph.Nodes, n)
+}
+
+func (b *builder) addEdge(source, target, relation string) {
+	key := source + "|" + target + "|" + relation
+	if b.edgeSeen[key] {
+		return
+	}
+	b.edgeSeen[key] = true
+	b.graph.Edges = append(b.graph.Edges, Edge{Source: source, Target: target, Relation: relation})
+}
+
+func nodeID(nodeType, label string) string {
+	return nodeType + ":" + label
+}
@@ -1,21 +1,25 @@
 package handler
 
 import (
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/etymograph/api/internal/cache"
 	"github.com/etymograph/api/internal/model"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 type AdminHandler struct {
-	db *gorm.DB
+	db    *gorm.DB
+	words *cache.WordCache
 }
 
-func NewAdminHandler(db *gorm.DB) *AdminHandler {
-	return &AdminHandler{db: db}
+func NewAdminHandler(db *gorm.DB, wordCache *cache.WordCache) *AdminHandler {
+	return &AdminHandler{db: db, words: wordCache}
 }
 
 type DashboardStats struct {
@@ -206,3 +210,51 @@ func (h *AdminHandler) GetSearchAnalytics(c *gin.Context) {
 		"words": results,
 	})
 }
+
+type PurgeCacheRequest struct {
+	Word     string `json:"word"`
+	Language string `json:"language"`
+	Version  string `json:"version"`
+}
+
+// PurgeCache deletes cached search responses by word, language and/or key version
+// (default: the current version)
+func (h *AdminHandler) PurgeCache(c *gin.Context) {
+	if h.words == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "cache is not available"})
+		return
+	}
+
+	var req PurgeCacheRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	if req.Word == "" && req.Language == "" && req.Version == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "word, language or version is required"})
+		return
+	}
+
+	filter := cache.PurgeFilter{Word: strings.TrimSpace(req.Word), Version: req.Version}
+	if req.Language != "" {
+		filter.Language = getLanguageKey(req.Language)
+	}
+	if filter.Version == "" {
+		filter.Version = h.words.KeyVersion()
+	}
+
+	deleted, err := h.words.PurgeWordCache(c.Request.Context(), filter)
+	if err != nil {
+		log.Printf("Cache purge failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to purge cache", "deleted": deleted})
+		return
+	}
+
+	log.Printf("Cache purged: word=%q language=%q version=%q (%d keys)", filter.Word, filter.Language, filter.Version, deleted)
+	c.JSON(http.StatusOK, gin.H{
+		"word":     filter.Word,
+		"language": filter.Language,
+		"version":  filter.Version,
+		"deleted":  deleted,
+	})
+}
@@ -3,13 +3,13 @@ package handler
 import (
 	"bytes"
 	"encoding/csv"
-	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
 
+	"github.com/etymograph/api/internal/etymology"
 	"github.com/etymograph/api/internal/model"
 	"github.com/gin-gonic/gin"
-	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
@@ -21,14 +21,50 @@ func NewExportHandler(db *gorm.DB) *ExportHandler {
 	return &ExportHandler{db: db}
 }
 
-// getLatestEtymology fetches the latest revision's etymology for a word
-func (h *ExportHandler) getLatestEtymology(wordID int64) datatypes.JSON {
+// getLatestEtymology fetches and decodes the latest revision's etymology for a word
+func (h *ExportHandler) getLatestEtymology(wordID int64) *etymology.Document {
 	var revision model.EtymologyRevision
 	err := h.db.Where("word_id = ?", wordID).Order("revision_number DESC").First(&revision).Error
 	if err != nil {
 		return nil
 	}
-	return revision.Etymology
+
+	doc, err := revision.Document()
+	if err != nil {
+		log.Printf("Export: failed to decode etymology revision %d: %v", revision.ID, err)
+		return nil
+	}
+	return doc
+}
+
+// exportFields holds the per-word values shared by the CSV and Markdown exporters
+type exportFields struct {
+	OriginLanguage string
+	OriginRoot     string
+	Evolution      string
+	Meaning        string
+}
+
+func newExportFields(doc *etymology.Document) exportFields {
+	if doc == nil {
+		return exportFields{}
+	}
+
+	fields := exportFields{
+		OriginLanguage: doc.OriginLanguage(),
+		OriginRoot:     doc.OriginRoot(),
+		Meaning:        doc.Brief(),
+	}
+	if doc.Kind == etymology.KindWord {
+		fields.Evolution = doc.Word.Evolution.Explanation
+		if fields.Evolution == "" {
+			fields.Evolution = doc.Word.Evolution.Path
+		}
+		if doc.Word.ModernMeaning != "" {
+			fields.Meaning = doc.Word.ModernMeaning
+		}
+	}
+	return fields
 }
 
 func (h *ExportHandler) Export(c *gin.Context) {
@@ -56,7 +92,7 @@ func (h *ExportHandler) Export(c *gin.Context) {
 }
 
 func (h *ExportHandler) exportJSON(c *gin.Context, session *model.Session) {
-	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=session-%s.json", session.ID))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=session-%d.json", session.ID))
 	c.JSON(http.StatusOK, session)
 }
 
@@ -68,39 +104,21 @@ func (h *ExportHandler) exportCSV(c *gin.Context, session *model.Session) {
 	writer.Write([]string{"Order", "Word", "Origin Language", "Origin Root", "Etymology"})
 
 	for _, sw := range session.Words {
-		var etymology map[string]interface{}
-		etymologyJSON := h.getLatestEtymology(sw.Word.ID)
-		json.Unmarshal(etymologyJSON, &etymology)
-
-		originLang := ""
-		originRoot := ""
-		if origin, ok := etymology["origin"].(map[string]interface{}); ok {
-			if lang, ok := origin["language"].(string); ok {
-				originLang = lang
-			}
-			if root, ok := origin["root"].(string); ok {
-				originRoot = root
-			}
-		}
-
-		evolution := ""
-		if ev, ok := etymology["evolution"].(string); ok {
-			evolution = ev
-		}
+		fields := newExportFields(h.getLatestEtymology(sw.Word.ID))
 
 		writer.Write([]string{
 			fmt.Sprintf("%d", sw.Order),
 			sw.Word.Word,
-			originLang,
-			originRoot,
-			evolution,
+			fields.OriginLanguage,
+			fields.OriginRoot,
+			fields.Evolution,
 		})
 	}
 
 	writer.Flush()
 
 	c.Header("Content-Type", "text/csv")
-	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=session-%s.csv", session.ID))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=session-%d.csv", session.ID))
 	c.Data(http.StatusOK, "text/csv", buf.Bytes())
 }
 
@@ -119,32 +137,26 @@ func (h *ExportHandler) exportMarkdown(c *gin.Context, session *model.Session) {
 	buf.WriteString("## Words\n\n")
 
 	for _, sw := range session.Words {
-		var etymology map[string]interface{}
-		etymologyJSON := h.getLatestEtymology(sw.Word.ID)
-		json.Unmarshal(etymologyJSON, &etymology)
+		fields := newExportFields(h.getLatestEtymology(sw.Word.ID))
 
 		buf.WriteString(fmt.Sprintf("### %d. %s\n\n", sw.Order, sw.Word.Word))
 
-		if origin, ok := etymology["origin"].(map[string]interface{}); ok {
-			if lang, ok := origin["language"].(string); ok {
-				if root, ok := origin["root"].(string); ok {
-					buf.WriteString(fmt.Sprintf("**Origin:** %s (*%s*)\n\n", lang, root))
-				}
-			}
+		if fields.OriginLanguage != "" && fields.OriginRoot != "" {
+			buf.WriteString(fmt.Sprintf("**Origin:** %s (*%s*)\n\n", fields.OriginLanguage, fields.OriginRoot))
 		}
 
-		if evolution, ok := etymology["evolution"].(string); ok {
-			buf.WriteString(fmt.Sprintf("**Evolution:** %s\n\n", evolution))
+		if fields.Evolution != "" {
+			buf.WriteString(fmt.Sprintf("**Evolution:** %s\n\n", fields.Evolution))
 		}
 
-		if meaning, ok := etymology["modernMeaning"].(string); ok {
-			buf.WriteString(fmt.Sprintf("**Meaning:** %s\n\n", meaning))
+		if fields.Meaning != "" {
+			buf.WriteString(fmt.Sprintf("**Meaning:** %s\n\n", fields.Meaning))
 		}
 
 		buf.WriteString("---\n\n")
 	}
 
 	c.Header("Content-Type", "text/markdown")
-	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=session-%s.md", session.ID))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=session-%d.md", session.ID))
 	c.Data(http.StatusOK, "text/markdown", buf.Bytes())
 }
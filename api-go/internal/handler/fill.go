@@ -2,10 +2,8 @@ package handler
 
 import (
 	"context"
-	"encoding/json"
 	"log"
 	"net/http"
-	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -15,7 +13,6 @@ import (
 	"github.com/etymograph/api/internal/model"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
-	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
@@ -33,6 +30,8 @@ type FillJob struct {
 	Status    string     `json:"status"` // running, completed, stopped, failed
 	Language  string     `json:"language"`
 	Workers   int        `json:"workers"`
+	BatchSize int        `json:"batchSize"`
+	Pack      bool       `json:"pack"`
 	DelayMs   int        `json:"delayMs"`
 	Total     int        `json:"total"`
 	Completed int64      `json:"completed"`
@@ -48,16 +47,18 @@ type JobError struct {
 }
 
 type FillRequest struct {
-	Language string `json:"language"`
-	Workers  int    `json:"workers"`
-	DelayMs  int    `json:"delayMs"`
+	Language  string `json:"language"`
+	Workers   int    `json:"workers"`
+	BatchSize int    `json:"batchSize"` // words per llm-proxy batch request
+	Pack      bool   `json:"pack"`      // let llm-proxy pack short words into one prompt
+	DelayMs   int    `json:"delayMs"`
 }
 
-func NewFillHandler(db *gorm.DB, redisCache *cache.RedisCache, llmProxyURL string) *FillHandler {
+func NewFillHandler(db *gorm.DB, redisCache *cache.RedisCache, llmProxyURL string, llmTimeout time.Duration) *FillHandler {
 	return &FillHandler{
 		db:        db,
 		cache:     redisCache,
-		llmClient: client.NewLLMClient(llmProxyURL),
+		llmClient: client.NewLLMClient(llmProxyURL, llmTimeout),
 		jobs:      make(map[string]*FillJob),
 		cancelFns: make(map[string]context.CancelFunc),
 	}
@@ -76,11 +77,17 @@ func (h *FillHandler) StartFill(c *gin.Context) {
 		req.Language = "Korean"
 	}
 	if req.Workers <= 0 {
-		req.Workers = 100 // Default 100 parallel workers
+		req.Workers = 10 // Default 10 parallel workers, each sending a batch at a time
 	}
 	if req.Workers > 100 {
 		req.Workers = 100 // Max 100 workers
 	}
+	if req.BatchSize <= 0 {
+		req.BatchSize = 10
+	}
+	if req.BatchSize > 50 {
+		req.BatchSize = 50 // llm-proxy's default ETYMOLOGY_BATCH_MAX_WORDS
+	}
 	if req.DelayMs <= 0 {
 		req.DelayMs = 3000 // Default 3000ms between requests per worker (~100 RPM with 5 workers)
 	}
@@ -122,6 +129,8 @@ func (h *FillHandler) StartFill(c *gin.Context) {
 		Status:    "running",
 		Language:  req.Language,
 		Workers:   req.Workers,
+		BatchSize: req.BatchSize,
+		Pack:      req.Pack,
 		DelayMs:   req.DelayMs,
 		Total:     int(total),
 		Completed: 0,
@@ -142,10 +151,11 @@ func (h *FillHandler) StartFill(c *gin.Context) {
 	go h.runFillJobParallel(ctx, job)
 
 	c.JSON(http.StatusOK, gin.H{
-		"jobId":   jobID,
-		"status":  "started",
-		"total":   total,
-		"workers": req.Workers,
+		"jobId":     jobID,
+		"status":    "started",
+		"total":     total,
+		"workers":   req.Workers,
+		"batchSize": req.BatchSize,
 	})
 }
 
@@ -188,11 +198,7 @@ func (h *FillHandler) StopFill(c *gin.Context) {
 	stoppedCount := 0
 	for jobID, job := range h.jobs {
 		if job.Status == "running" {
-			if cancel, exists := h.cancelFns[jobID]; exists {
-				cancel()
-				delete(h.cancelFns, jobID)
-			}
-			job.Status = "stopped"
+			h.stopJobLocked(jobID, job)
 			stoppedCount++
 		}
 	}
@@ -203,6 +209,25 @@ func (h *FillHandler) StopFill(c *gin.Context) {
 	})
 }
 
+// stopJob cancels a running job's workers and marks it stopped
+func (h *FillHandler) stopJob(jobID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if job, exists := h.jobs[jobID]; exists && job.Status == "running" {
+		h.stopJobLocked(jobID, job)
+	}
+}
+
+// stopJobLocked is stopJob for callers holding h.mu
+func (h *FillHandler) stopJobLocked(jobID string, job *FillJob) {
+	if cancel, exists := h.cancelFns[jobID]; exists {
+		cancel()
+		delete(h.cancelFns, jobID)
+	}
+	job.Status = "stopped"
+}
+
 // ListJobs returns all jobs
 func (h *FillHandler) ListJobs(c *gin.Context) {
 	h.mu.RLock()
@@ -228,7 +253,7 @@ func (h *FillHandler) runFillJobParallel(ctx context.Context, job *FillJob) {
 	var processingMu sync.Mutex
 
 	// Create a channel for words to process
-	wordChan := make(chan model.Word, job.Workers*2)
+	wordChan := make(chan model.Word, job.Workers*job.BatchSize)
 
 	// Start worker goroutines
 	var wg sync.WaitGroup
@@ -252,7 +277,7 @@ func (h *FillHandler) runFillJobParallel(ctx context.Context, job *FillJob) {
 				var words []model.Word
 				result := h.db.Where("language = ? AND id NOT IN (SELECT DISTINCT word_id FROM etymology_revisions)", langKey).
 					Order("id ASC").
-					Limit(job.Workers * 2).
+					Limit(job.Workers * job.BatchSize).
 					Find(&words)
 
 				if result.Error != nil {
@@ -307,96 +332,161 @@ func (h *FillHandler) runFillJobParallel(ctx context.Context, job *FillJob) {
 	log.Printf("[FillJob %s] Finished - completed: %d, failed: %d", job.JobID, atomic.LoadInt64(&job.Completed), atomic.LoadInt64(&job.Failed))
 }
 
-// worker processes words from the channel
+// worker processes words from the channel, a batch at a time
 func (h *FillHandler) worker(ctx context.Context, job *FillJob, wordChan <-chan model.Word, workerID int, delay time.Duration, processing map[int64]bool, processingMu *sync.Mutex) {
 	maxRetries := 3
 	retryDelay := 30 * time.Second
 
 	for {
-		select {
-		case <-ctx.Done():
+		batch, ok := nextBatch(ctx, wordChan, job.BatchSize)
+		if !ok {
 			return
-		case word, ok := <-wordChan:
-			if !ok {
-				return
-			}
+		}
 
-			// Double-check: skip if already has a revision (safety net for race conditions)
+		// Double-check: skip words that already have a revision (safety net for race conditions)
+		words := make([]model.Word, 0, len(batch))
+		for _, word := range batch {
 			var revCount int64
 			h.db.Model(&model.EtymologyRevision{}).Where("word_id = ?", word.ID).Count(&revCount)
 			if revCount > 0 {
-				// Already has revision, remove from processing and skip
 				processingMu.Lock()
 				delete(processing, word.ID)
 				processingMu.Unlock()
 				continue
 			}
+			words = append(words, word)
+		}
+		if len(words) == 0 {
+			continue
+		}
 
-			// Try to fetch etymology with retries
-			var etymology map[string]interface{}
-			var err error
-			for retry := 0; retry < maxRetries; retry++ {
-				etymology, err = h.llmClient.GetEtymologyWithLang(word.Word, job.Language)
-				if err == nil {
-					break
-				}
+		names := make([]string, len(words))
+		for i, word := range words {
+			names[i] = word.Word
+		}
 
-				errMsg := err.Error()
-				if strings.Contains(errMsg, "429") || strings.Contains(errMsg, "quota") || strings.Contains(errMsg, "RESOURCE_EXHAUSTED") {
-					log.Printf("[Worker %d] Rate limited on %s, waiting %v before retry %d/%d",
-						workerID, word.Word, retryDelay, retry+1, maxRetries)
+		// Fetch the batch, retrying when llm-proxy rejects the whole request for rate limits
+		// (words rate limited at the provider are already retried by llm-proxy)
+		var results []client.BatchEtymology
+		var err error
+		for retry := 0; retry < maxRetries; retry++ {
+			results, err = h.llmClient.GetEtymologyBatch(ctx, names, job.Language, job.Pack)
+			if err == nil || ctx.Err() != nil {
+				break
+			}
 
-					select {
-					case <-ctx.Done():
-						return
-					case <-time.After(retryDelay):
-						continue
-					}
-				} else {
-					// Non-retryable error
-					break
-				}
+			upstream, ok := client.AsUpstreamError(err)
+			if !ok || !upstream.RateLimited() {
+				// Non-retryable error
+				break
+			}
+			if upstream.Code == client.CodeBudgetExceeded {
+				// Nothing will succeed until the budget resets; leave the words for a later job
+				log.Printf("[FillJob %s] LLM daily budget exceeded, stopping job", job.JobID)
+				h.stopJob(job.JobID)
+				return
 			}
 
-			if err != nil {
-				log.Printf("[Worker %d] Error fetching etymology for %s: %v", workerID, word.Word, err)
-				atomic.AddInt64(&job.Failed, 1)
-				job.mu.Lock()
-				if len(job.Errors) < 100 { // Limit error history
-					job.Errors = append(job.Errors, JobError{Word: word.Word, Error: err.Error()})
-				}
-				job.mu.Unlock()
-			} else {
-				// Save etymology as revision
-				etymologyJSON, _ := json.Marshal(etymology)
-				revision := model.EtymologyRevision{
-					WordID:         word.ID,
-					RevisionNumber: 1,
-					Etymology:      datatypes.JSON(etymologyJSON),
-				}
-				if err := h.db.Create(&revision).Error; err != nil {
-					log.Printf("[Worker %d] Error saving %s: %v", workerID, word.Word, err)
-					atomic.AddInt64(&job.Failed, 1)
-				} else {
-					atomic.AddInt64(&job.Completed, 1)
-					completed := atomic.LoadInt64(&job.Completed)
-					if completed%100 == 0 {
-						log.Printf("[FillJob %s] Progress: %d/%d completed", job.JobID, completed, job.Total)
-					}
-				}
+			wait := retryDelay
+			if upstream.RetryAfter > wait {
+				wait = upstream.RetryAfter
 			}
+			log.Printf("[Worker %d] Rate limited on batch of %d words, waiting %v before retry %d/%d",
+				workerID, len(words), wait, retry+1, maxRetries)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(wait):
+			}
+		}
+
+		// The job was stopped mid-request; the words stay unfilled for the next job
+		if ctx.Err() != nil {
+			return
+		}
+
+		for i, word := range words {
+			result := client.BatchEtymology{Word: word.Word, Err: err}
+			if err == nil {
+				result = results[i]
+			}
+			h.saveFillResult(job, workerID, word, result)
 
 			// Remove from processing set after completion
 			processingMu.Lock()
 			delete(processing, word.ID)
 			processingMu.Unlock()
+		}
 
-			// Delay before next request
-			select {
-			case <-ctx.Done():
-				return
-			case <-time.After(delay):
+		// Delay before next request
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+// nextBatch waits for one word, then takes up to size-1 more that are already queued.
+// It returns false once the channel is closed and drained or ctx is done.
+func nextBatch(ctx context.Context, wordChan <-chan model.Word, size int) ([]model.Word, bool) {
+	var batch []model.Word
+	select {
+	case <-ctx.Done():
+		return nil, false
+	case word, ok := <-wordChan:
+		if !ok {
+			return nil, false
+		}
+		batch = append(batch, word)
+	}
+
+	for len(batch) < size {
+		select {
+		case word, ok := <-wordChan:
+			if !ok {
+				return batch, true
 			}
+			batch = append(batch, word)
+		default:
+			return batch, true
+		}
+	}
+	return batch, true
+}
+
+// saveFillResult stores one word's etymology as its first revision, or records its error
+func (h *FillHandler) saveFillResult(job *FillJob, workerID int, word model.Word, result client.BatchEtymology) {
+	if result.Err != nil {
+		log.Printf("[Worker %d] Error fetching etymology for %s: %v", workerID, word.Word, result.Err)
+		atomic.AddInt64(&job.Failed, 1)
+		job.mu.Lock()
+		if len(job.Errors) < 100 { // Limit error history
+			job.Errors = append(job.Errors, JobError{Word: word.Word, Error: result.Err.Error()})
+		}
+		job.mu.Unlock()
+		return
+	}
+
+	// Save etymology as revision
+	revision := model.EtymologyRevision{
+		WordID:         word.ID,
+		RevisionNumber: 1,
+		PromptID:       result.Prompt.ID,
+		PromptVersion:  result.Prompt.Version,
+	}
+	if err := revision.SetDocument(result.Doc); err != nil {
+		log.Printf("[Worker %d] Error encoding %s: %v", workerID, word.Word, err)
+		atomic.AddInt64(&job.Failed, 1)
+	} else if err := h.db.Create(&revision).Error; err != nil {
+		log.Printf("[Worker %d] Error saving %s: %v", workerID, word.Word, err)
+		atomic.AddInt64(&job.Failed, 1)
+	} else {
+		atomic.AddInt64(&job.Completed, 1)
+		completed := atomic.LoadInt64(&job.Completed)
+		if completed%100 == 0 {
+			log.Printf("[FillJob %s] Progress: %d/%d completed", job.JobID, completed, job.Total)
 		}
 	}
 }
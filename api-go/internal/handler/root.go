@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/etymograph/api/internal/model"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// RootHandler serves cross-word lookups from the root/morpheme index
+type RootHandler struct {
+	db *gorm.DB
+}
+
+func NewRootHandler(db *gorm.DB) *RootHandler {
+	return &RootHandler{db: db}
+}
+
+// RelatedWord is a word found through a shared root or morpheme
+type RelatedWord struct {
+	ID       int64  `json:"id"`
+	Word     string `json:"word"`
+	Language string `json:"language"`
+}
+
+// GetWordsByRoot returns words whose etymology has the given origin root
+// e.g. GET /api/roots/videre/words → view, review, interview
+func (h *RootHandler) GetWordsByRoot(c *gin.Context) {
+	h.listWords(c, "root", "word_roots", "root", c.Param("root"))
+}
+
+// GetWordsByMorpheme returns words whose etymology has the given component
+// e.g. GET /api/morphemes/-tion/words
+func (h *RootHandler) GetWordsByMorpheme(c *gin.Context) {
+	h.listWords(c, "part", "word_morphemes", "part", c.Param("part"))
+}
+
+// listWords queries an index table for words whose latest revision matches value
+// (paginated, excluding ?exclude=). Older revisions stay indexed but are not matched.
+func (h *RootHandler) listWords(c *gin.Context, responseKey, table, column, value string) {
+	value = model.NormalizeMorpheme(value)
+	if value == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": responseKey + " is required"})
+		return
+	}
+
+	language := c.Query("language")
+	if language == "" {
+		language = "Korean"
+	}
+	langKey := getLanguageKey(language)
+
+	limit := 50
+	offset := 0
+	if l := c.Query("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 200 {
+			limit = parsed
+		}
+	}
+	if o := c.Query("offset"); o != "" {
+		if parsed, err := strconv.Atoi(o); err == nil && parsed >= 0 {
+			offset = parsed
+		}
+	}
+
+	query := h.db.Model(&model.Word{}).
+		Where("language = ?", langKey).
+		Where(fmt.Sprintf(`id IN (
+			SELECT ix.word_id FROM %s ix
+			WHERE ix.%s = ? AND ix.revision_id = (
+				SELECT er.id FROM etymology_revisions er
+				WHERE er.word_id = ix.word_id
+				ORDER BY er.revision_number DESC
+				LIMIT 1
+			)
+		)`, table, column), value)
+	if exclude := model.NormalizeMorpheme(c.Query("exclude")); exclude != "" {
+		query = query.Where("word <> ?", exclude)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	words := []RelatedWord{}
+	if err := query.Select("id, word, language").
+		Order("word ASC").
+		Limit(limit).
+		Offset(offset).
+		Scan(&words).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch words"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		responseKey: value,
+		"language":  langKey,
+		"words":     words,
+		"total":     total,
+		"limit":     limit,
+		"offset":    offset,
+	})
+}
@@ -1,11 +1,14 @@
 package handler
 
 import (
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/etymograph/api/internal/etymology"
+	"github.com/etymograph/api/internal/graph"
 	"github.com/etymograph/api/internal/model"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -146,3 +149,91 @@ func (h *SessionHandler) RemoveWord(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Word removed from session"})
 }
+
+// Delete removes a session and all of its words
+func (h *SessionHandler) Delete(c *gin.Context) {
+	sessionID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	var deleted int64
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("session_id = ?", sessionID).Delete(&model.SessionWord{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&model.Session{}, sessionID)
+		deleted = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete session"})
+		return
+	}
+	if deleted == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session deleted"})
+}
+
+// GetGraph builds the word → root → component → derivative graph for a session.
+// Each word uses the caller's preferred revision when logged in, otherwise the latest.
+func (h *SessionHandler) GetGraph(c *gin.Context) {
+	sessionID := c.Param("id")
+
+	var session model.Session
+	result := h.db.Preload("Words", func(db *gorm.DB) *gorm.DB {
+		return db.Order("\"order\" ASC")
+	}).Preload("Words.Word").First(&session, "id = ?", sessionID)
+	if result.Error != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+
+	var userID int64
+	if id, exists := c.Get("userID"); exists {
+		userID = id.(int64)
+	}
+	wordIDs := make([]int64, 0, len(session.Words))
+	for _, sw := range session.Words {
+		wordIDs = append(wordIDs, sw.WordID)
+	}
+	revisions, err := findRevisions(h.db, userID, wordIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load etymology"})
+		return
+	}
+
+	entries := make([]graph.Entry, 0, len(session.Words))
+	for _, sw := range session.Words {
+		entry := graph.Entry{
+			SessionWordID: sw.ID,
+			ParentID:      sw.ParentID,
+			Word:          sw.Word.Word,
+			Order:         sw.Order,
+		}
+
+		if revision := revisions[sw.WordID]; revision != nil {
+			entry.Revision = revision.RevisionNumber
+			if doc, err := revision.Document(); err == nil {
+				entry.Etymology = doc
+			} else if err != etymology.ErrEmptyDocument {
+				log.Printf("Session graph: failed to decode revision %d: %v", revision.ID, err)
+			}
+		}
+
+		entries = append(entries, entry)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"session": gin.H{
+			"id":        session.ID,
+			"name":      session.Name,
+			"createdAt": session.CreatedAt,
+		},
+		"graph": graph.Build(entries),
+	})
+}
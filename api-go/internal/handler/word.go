@@ -3,38 +3,96 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/etymograph/api/internal/cache"
 	"github.com/etymograph/api/internal/client"
 	"github.com/etymograph/api/internal/config"
+	"github.com/etymograph/api/internal/etymology"
 	"github.com/etymograph/api/internal/filter"
 	"github.com/etymograph/api/internal/model"
+	"github.com/etymograph/api/internal/suggest"
 	"github.com/etymograph/api/internal/validator"
 	"github.com/gin-gonic/gin"
-	"gorm.io/datatypes"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 const MaxRevisions = 3
 
+// generateLockMargin is added to the LLM timeout for the generation lock's TTL,
+// so the lock outlives its holder's LLM call and save
+const generateLockMargin = 30 * time.Second
+
+// generatePollInterval is how often a search waiting on another replica's
+// generation checks whether the word has been saved
+const generatePollInterval = 500 * time.Millisecond
+
 type WordHandler struct {
-	db            *gorm.DB
-	cache         *cache.RedisCache
-	llmClient     *client.LLMClient
-	wordValidator *validator.WordValidator
+	db              *gorm.DB
+	cache           *cache.RedisCache
+	words           *cache.WordCache
+	suggestions     *cache.LRU
+	suggester       *suggest.Index
+	llmClient       *client.LLMClient
+	wordValidator   *validator.WordValidator
+	llmTimeout      time.Duration
+	generateLockTTL time.Duration
+
+	generatingMu sync.Mutex
+	generating   map[string]*generation // in-process first revision generations by cache key
+}
+
+// generation is one first revision generation shared by concurrent searches.
+// Its fields other than done, response and err are guarded by generatingMu.
+type generation struct {
+	done     chan struct{}
+	response *model.WordWithEtymology
+	err      error
+
+	cancel  context.CancelFunc
+	waiters int
+	// progress has a channel per waiter that wants the LLM output as it arrives;
+	// output is the text so far, replayed to waiters that join late
+	progress map[chan string]struct{}
+	output   strings.Builder
+}
+
+// progressBuffer is how many chunks a waiter may fall behind before it misses some
+const progressBuffer = 64
+
+// generateFunc fetches a new word's etymology from the LLM, passing its raw output
+// to progress as it is generated when the LLM call streams
+type generateFunc func(ctx context.Context, progress func(text string)) (*etymology.Document, client.PromptRef, error)
+
+// saveError is returned for a generated etymology that could not be stored;
+// its message is shown to the client
+type saveError struct {
+	message string
 }
 
-func NewWordHandler(db *gorm.DB, redisCache *cache.RedisCache, cfg *config.Config, wordValidator *validator.WordValidator) *WordHandler {
+func (e *saveError) Error() string {
+	return e.message
+}
+
+func NewWordHandler(db *gorm.DB, redisCache *cache.RedisCache, wordCache *cache.WordCache, cfg *config.Config, wordValidator *validator.WordValidator, suggester *suggest.Index) *WordHandler {
 	return &WordHandler{
-		db:            db,
-		cache:         redisCache,
-		llmClient:     client.NewLLMClient(cfg.LLMProxyURL),
-		wordValidator: wordValidator,
+		db:              db,
+		cache:           redisCache,
+		words:           wordCache,
+		suggestions:     cache.NewLRU("suggest", cfg.CacheL1MaxEntries, cfg.CacheL1MaxBytes, cfg.CacheL1TTL),
+		llmClient:       client.NewLLMClient(cfg.LLMProxyURL, cfg.LLMTimeout),
+		wordValidator:   wordValidator,
+		suggester:       suggester,
+		llmTimeout:      cfg.LLMTimeout,
+		generateLockTTL: cfg.LLMTimeout + generateLockMargin,
+		generating:      make(map[string]*generation),
 	}
 }
 
@@ -61,31 +119,92 @@ func getLanguageKey(language string) string {
 
 // getLatestRevision returns the latest revision for a word
 func (h *WordHandler) getLatestRevision(wordID int64) (*model.EtymologyRevision, error) {
+	return findLatestRevision(h.db, wordID)
+}
+
+// getUserPreferredRevision returns user's preferred revision or latest if no preference
+func (h *WordHandler) getUserPreferredRevision(userID, wordID int64) (*model.EtymologyRevision, error) {
+	return findPreferredRevision(h.db, userID, wordID)
+}
+
+// findLatestRevision returns the latest revision for a word
+func findLatestRevision(db *gorm.DB, wordID int64) (*model.EtymologyRevision, error) {
 	var revision model.EtymologyRevision
-	result := h.db.Where("word_id = ?", wordID).Order("revision_number DESC").First(&revision)
+	result := db.Where("word_id = ?", wordID).Order("revision_number DESC").First(&revision)
 	if result.Error != nil {
 		return nil, result.Error
 	}
 	return &revision, nil
 }
 
-// getUserPreferredRevision returns user's preferred revision or latest if no preference
+// findPreferredRevision returns user's preferred revision or latest if no preference
+func findPreferredRevision(db *gorm.DB, userID, wordID int64) (*model.EtymologyRevision, error) {
+	if revision, err := findSelectedRevision(db, userID, wordID); err == nil && revision != nil {
+		return revision, nil
+	}
+	// Fallback to latest revision
+	return findLatestRevision(db, wordID)
+}
+
+// findSelectedRevision returns the revision a user selected for a word, or nil if none
 // Optimized: uses JOIN to fetch in a single query
-func (h *WordHandler) getUserPreferredRevision(userID, wordID int64) (*model.EtymologyRevision, error) {
+func findSelectedRevision(db *gorm.DB, userID, wordID int64) (*model.EtymologyRevision, error) {
 	var revision model.EtymologyRevision
 	// Single query with JOIN: preference -> revision
-	result := h.db.Raw(`
+	result := db.Raw(`
 		SELECT er.* FROM etymology_revisions er
 		INNER JOIN user_etymology_preferences uep ON er.id = uep.revision_id
 		WHERE uep.user_id = ? AND uep.word_id = ?
 		LIMIT 1
 	`, userID, wordID).Scan(&revision)
 
-	if result.Error == nil && revision.ID > 0 {
-		return &revision, nil
+	if result.Error != nil {
+		return nil, result.Error
 	}
-	// Fallback to latest revision
-	return h.getLatestRevision(wordID)
+	if revision.ID == 0 {
+		return nil, nil
+	}
+	return &revision, nil
+}
+
+// findRevisions returns the revision each word should show, keyed by word ID: the
+// user's selected revision where there is one (userID 0 for none), otherwise the latest.
+// It takes one query for the latest revisions and one for the selections.
+func findRevisions(db *gorm.DB, userID int64, wordIDs []int64) (map[int64]*model.EtymologyRevision, error) {
+	revisions := make(map[int64]*model.EtymologyRevision, len(wordIDs))
+	if len(wordIDs) == 0 {
+		return revisions, nil
+	}
+
+	var latest []model.EtymologyRevision
+	result := db.Raw(`
+		SELECT DISTINCT ON (word_id) * FROM etymology_revisions
+		WHERE word_id IN ?
+		ORDER BY word_id, revision_number DESC
+	`, wordIDs).Scan(&latest)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	for i := range latest {
+		revisions[latest[i].WordID] = &latest[i]
+	}
+
+	if userID == 0 {
+		return revisions, nil
+	}
+	var selected []model.EtymologyRevision
+	result = db.Raw(`
+		SELECT er.* FROM etymology_revisions er
+		INNER JOIN user_etymology_preferences uep ON er.id = uep.revision_id
+		WHERE uep.user_id = ? AND uep.word_id IN ?
+	`, userID, wordIDs).Scan(&selected)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	for i := range selected {
+		revisions[selected[i].WordID] = &selected[i]
+	}
+	return revisions, nil
 }
 
 // getRevisionSummaries returns a list of revision summaries for a word
@@ -97,6 +216,8 @@ func (h *WordHandler) getRevisionSummaries(wordID int64) []model.RevisionSummary
 	for i, rev := range revisions {
 		summaries[i] = model.RevisionSummary{
 			RevisionNumber: rev.RevisionNumber,
+			PromptID:       rev.PromptID,
+			PromptVersion:  rev.PromptVersion,
 			CreatedAt:      rev.CreatedAt,
 		}
 	}
@@ -132,10 +253,98 @@ func (h *WordHandler) buildWordResponse(word *model.Word, revision *model.Etymol
 }
 
 func (h *WordHandler) Search(c *gin.Context) {
+	normalizedWord, language, ok := h.bindSearch(c)
+	if !ok {
+		return
+	}
+	langKey := getLanguageKey(language)
+
+	// 1-2. Redis cache, then PostgreSQL
+	response, word := h.findSearchResult(c, normalizedWord, langKey)
+	if response != nil {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	if !h.validateNewWord(c, normalizedWord) {
+		return
+	}
+
+	// Fetch etymology from LLM with specified language
+	response, err := h.generateFirstRevision(c.Request.Context(), word, normalizedWord, langKey, func(ctx context.Context, progress func(string)) (*etymology.Document, client.PromptRef, error) {
+		log.Printf("Fetching etymology for: %s (language: %s)", normalizedWord, language)
+		return h.llmClient.GetEtymologyWithLang(ctx, normalizedWord, language)
+	}, nil)
+	var saveErr *saveError
+	if errors.As(err, &saveErr) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": saveErr.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Error fetching etymology: %v", err)
+		c.JSON(llmErrorResponse(err, "Failed to fetch etymology"))
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// SearchStream is the server-sent events variant of Search.
+// While a new word is generated it emits "progress" events ({"text"}) with the partial
+// LLM output, then a final "result" event with the persisted WordWithEtymology, or an
+// "error" event ({"error", "code"}). Words already stored get the "result" event at once.
+func (h *WordHandler) SearchStream(c *gin.Context) {
+	normalizedWord, language, ok := h.bindSearch(c)
+	if !ok {
+		return
+	}
+	langKey := getLanguageKey(language)
+
+	response, word := h.findSearchResult(c, normalizedWord, langKey)
+	if response == nil && !h.validateNewWord(c, normalizedWord) {
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	if response != nil {
+		streamEvent(c, "result", response)
+		return
+	}
+
+	// Searches that join a running generation get its output so far as their first
+	// "progress" event; one that joins a non-streaming generation gets none
+	response, err := h.generateFirstRevision(c.Request.Context(), word, normalizedWord, langKey, func(ctx context.Context, progress func(string)) (*etymology.Document, client.PromptRef, error) {
+		log.Printf("Streaming etymology for: %s (language: %s)", normalizedWord, language)
+		return h.llmClient.StreamEtymologyWithLang(ctx, normalizedWord, language, progress)
+	}, func(text string) {
+		streamEvent(c, "progress", gin.H{"text": text})
+	})
+	var saveErr *saveError
+	if errors.As(err, &saveErr) {
+		streamEvent(c, "error", gin.H{"error": saveErr.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Error streaming etymology: %v", err)
+		_, body := llmErrorResponse(err, "Failed to fetch etymology")
+		streamEvent(c, "error", body)
+		return
+	}
+
+	streamEvent(c, "result", response)
+}
+
+// bindSearch parses a search request, records search history and returns the
+// normalized word and language. It writes a 400 and returns false for invalid requests.
+func (h *WordHandler) bindSearch(c *gin.Context) (string, string, bool) {
 	var req SearchRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "word is required"})
-		return
+		return "", "", false
 	}
 
 	normalizedWord := strings.ToLower(strings.TrimSpace(req.Word))
@@ -151,129 +360,365 @@ func (h *WordHandler) Search(c *gin.Context) {
 			"error": "Invalid search term",
 			"code":  "INVALID_SEARCH_TERM",
 		})
-		return
+		return "", "", false
 	}
 
 	language := req.Language
 	if language == "" {
 		language = "Korean"
 	}
-	langKey := getLanguageKey(language)
-	cacheKey := cache.CacheKey(normalizedWord, langKey)
+	return normalizedWord, language, true
+}
 
-	// 1. Check Redis cache first
-	if h.cache != nil {
-		if cached, err := h.cache.Get(c.Request.Context(), cacheKey); err == nil {
-			var response model.WordWithEtymology
-			if err := json.Unmarshal(cached, &response); err == nil {
-				log.Printf("Redis cache hit: %s", cacheKey)
-				c.JSON(http.StatusOK, response)
-				return
+// findSearchResult returns the response for a word that already has an etymology,
+// checking Redis and then PostgreSQL. Logged-in users get their preferred revision.
+// The stored word, if any, is returned as well so a new revision can be attached to it.
+func (h *WordHandler) findSearchResult(c *gin.Context, normalizedWord, langKey string) (*model.WordWithEtymology, *model.Word) {
+	var userID int64
+	if id, exists := c.Get("userID"); exists {
+		userID = id.(int64)
+	}
+	return h.lookupSearchResult(c.Request.Context(), userID, normalizedWord, langKey)
+}
+
+// lookupSearchResult is findSearchResult for a user ID (0 = anonymous)
+func (h *WordHandler) lookupSearchResult(ctx context.Context, userID int64, normalizedWord, langKey string) (*model.WordWithEtymology, *model.Word) {
+
+	// 1. Check the word cache (in-process LRU, then Redis) first: resolve the revision, then read its response
+	if h.words != nil {
+		if revNum, ok := h.cachedRevisionNumber(ctx, userID, normalizedWord, langKey); ok {
+			if cached, err := h.words.GetWordResponse(ctx, normalizedWord, langKey, revNum); err == nil {
+				var response model.WordWithEtymology
+				if err := json.Unmarshal(cached, &response); err == nil {
+					log.Printf("Redis cache hit: %s (revision %d)", cache.CacheKey(normalizedWord, langKey), revNum)
+					return &response, nil
+				}
 			}
 		}
 	}
 
 	// 2. Check PostgreSQL for existing word
 	var word model.Word
-	result := h.db.Where("word = ? AND language = ?", normalizedWord, langKey).First(&word)
+	if err := h.db.Where("word = ? AND language = ?", normalizedWord, langKey).First(&word).Error; err != nil {
+		return nil, nil
+	}
 
-	if result.Error == nil {
-		// Word exists, get appropriate revision
-		var revision *model.EtymologyRevision
+	// Word exists, get appropriate revision
+	var revision *model.EtymologyRevision
+	var preferenceErr error
+	preferred := 0 // revision number the user selected; 0 = none, show the latest
+	if userID != 0 {
+		revision, preferenceErr = findSelectedRevision(h.db, userID, word.ID)
+		if revision != nil {
+			preferred = revision.RevisionNumber
+		}
+	}
+	if revision == nil {
 		var err error
-
-		if userID, exists := c.Get("userID"); exists {
-			revision, err = h.getUserPreferredRevision(userID.(int64), word.ID)
-		} else {
-			revision, err = h.getLatestRevision(word.ID)
+		if revision, err = h.getLatestRevision(word.ID); err != nil {
+			return nil, &word
 		}
+	}
 
-		if err == nil && revision != nil {
-			log.Printf("DB cache hit: %s (language: %s)", normalizedWord, langKey)
-			response := h.buildWordResponse(&word, revision, true)
+	log.Printf("DB cache hit: %s (language: %s)", normalizedWord, langKey)
+	response := h.buildWordResponse(&word, revision, true)
 
-			// Store in Redis for next time
-			if h.cache != nil {
-				if responseJSON, err := json.Marshal(response); err == nil {
-					h.cache.Set(c.Request.Context(), cacheKey, responseJSON)
-				}
-			}
-			c.JSON(http.StatusOK, response)
-			return
+	// Store in Redis for next time
+	if h.words != nil {
+		if userID != 0 && preferenceErr == nil {
+			h.words.SetPreference(ctx, userID, normalizedWord, langKey, preferred)
+		}
+		if preferred == 0 {
+			h.words.SetLatestRevision(ctx, normalizedWord, langKey, revision.RevisionNumber)
 		}
+		h.cacheResponse(ctx, &response)
 	}
+	return &response, &word
+}
 
-	// Validate word before calling LLM (only for new words)
-	// Skip validation for suffixes (-er) and prefixes (un-)
-	isSuffixOrPrefix := strings.HasPrefix(normalizedWord, "-") || strings.HasSuffix(normalizedWord, "-")
-	if h.wordValidator != nil && !isSuffixOrPrefix {
-		isValid, err := h.wordValidator.IsValidWord(normalizedWord)
-		if err != nil {
-			log.Printf("Word validation error: %v", err)
-		}
-		if !isValid {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": "Invalid word",
-				"code":  "INVALID_WORD",
-				"word":  normalizedWord,
-			})
-			return
+// cachedRevisionNumber resolves the revision a user (0 = anonymous) sees from the cached
+// preference and latest revision number. ok is false if either is not cached.
+func (h *WordHandler) cachedRevisionNumber(ctx context.Context, userID int64, normalizedWord, langKey string) (int, bool) {
+	if userID != 0 {
+		preferred, ok := h.words.GetPreference(ctx, userID, normalizedWord, langKey)
+		if !ok {
+			return 0, false
+		}
+		if preferred > 0 {
+			return preferred, true
 		}
 	}
+	return h.words.GetLatestRevision(ctx, normalizedWord, langKey)
+}
 
-	// Fetch etymology from LLM with specified language
-	log.Printf("Fetching etymology for: %s (language: %s)", normalizedWord, language)
-	etymology, err := h.llmClient.GetEtymologyWithLang(normalizedWord, language)
+// cacheResponse stores a search response under its revision's cache key
+func (h *WordHandler) cacheResponse(ctx context.Context, response *model.WordWithEtymology) {
+	responseJSON, err := json.Marshal(response)
 	if err != nil {
-		log.Printf("Error fetching etymology: %v", err)
-		errMsg := err.Error()
-		if strings.Contains(errMsg, "429") || strings.Contains(errMsg, "quota") || strings.Contains(errMsg, "RESOURCE_EXHAUSTED") {
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error": "Rate limit exceeded. Please wait a moment.",
-				"code":  "RATE_LIMIT_EXCEEDED",
-			})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch etymology"})
 		return
 	}
+	if err := h.words.SetWordResponse(ctx, response.Word, response.Language, response.CurrentRevision, responseJSON); errors.Is(err, cache.ErrValueTooLarge) {
+		log.Printf("Not caching %s revision %d: %d bytes", cache.CacheKey(response.Word, response.Language), response.CurrentRevision, len(responseJSON))
+	}
+}
 
-	etymologyJSON, _ := json.Marshal(etymology)
+// validateNewWord checks a word before calling the LLM for it (only for new words).
+// Suffixes (-er) and prefixes (un-) are not validated. It writes a 400 and returns false
+// for invalid words, with the closest dictionary words in "didYouMean".
+func (h *WordHandler) validateNewWord(c *gin.Context, normalizedWord string) bool {
+	isSuffixOrPrefix := strings.HasPrefix(normalizedWord, "-") || strings.HasSuffix(normalizedWord, "-")
+	if h.wordValidator == nil || isSuffixOrPrefix {
+		return true
+	}
 
-	// Create or get word record
-	if result.Error != nil {
-		word = model.Word{
-			Word:     normalizedWord,
-			Language: langKey,
-		}
-		if err := h.db.Create(&word).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save word"})
-			return
+	isValid, err := h.wordValidator.IsValidWord(normalizedWord)
+	if err != nil {
+		log.Printf("Word validation error: %v", err)
+	}
+	if !isValid {
+		didYouMean := []string{}
+		if h.suggester != nil {
+			didYouMean = append(didYouMean, h.suggester.Corrections(normalizedWord, 3)...)
 		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":      "Invalid word",
+			"code":       "INVALID_WORD",
+			"word":       normalizedWord,
+			"didYouMean": didYouMean,
+		})
+		return false
 	}
+	return true
+}
 
-	// Create first revision
+// saveSearchResult stores a newly fetched etymology as the word's first revision,
+// creating the word if needed, and caches the response.
+// Returned errors are *saveError values carrying the message to show the client.
+func (h *WordHandler) saveSearchResult(ctx context.Context, word *model.Word, normalizedWord, langKey string, doc *etymology.Document, prompt client.PromptRef) (*model.WordWithEtymology, error) {
 	revision := model.EtymologyRevision{
-		WordID:         word.ID,
 		RevisionNumber: 1,
-		Etymology:      datatypes.JSON(etymologyJSON),
+		PromptID:       prompt.ID,
+		PromptVersion:  prompt.Version,
 		CreatedAt:      time.Now(),
 	}
+	if err := revision.SetDocument(doc); err != nil {
+		log.Printf("Error encoding etymology for %s: %v", normalizedWord, err)
+		return nil, &saveError{"Failed to fetch etymology"}
+	}
+
+	// Create or get word record (another writer may have created it meanwhile)
+	if word == nil {
+		word = &model.Word{
+			Word:     normalizedWord,
+			Language: langKey,
+		}
+		if err := h.db.Clauses(clause.OnConflict{DoNothing: true}).Create(word).Error; err != nil {
+			return nil, &saveError{"Failed to save word"}
+		}
+		if word.ID == 0 {
+			if err := h.db.Where("word = ? AND language = ?", normalizedWord, langKey).First(word).Error; err != nil {
+				return nil, &saveError{"Failed to save word"}
+			}
+		}
+	}
+
+	// Create first revision, keeping the existing one if another writer (e.g. a fill job) won
+	revision.WordID = word.ID
 	if err := h.db.Create(&revision).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save etymology revision"})
-		return
+		if h.db.Where("word_id = ? AND revision_number = ?", word.ID, 1).First(&revision).Error != nil {
+			return nil, &saveError{"Failed to save etymology revision"}
+		}
+		log.Printf("Revision 1 of %s already exists, discarding the new etymology", normalizedWord)
 	}
 
-	response := h.buildWordResponse(&word, &revision, true)
+	response := h.buildWordResponse(word, &revision, true)
 
 	// Store in Redis cache
-	if h.cache != nil {
-		if responseJSON, err := json.Marshal(response); err == nil {
-			h.cache.Set(c.Request.Context(), cacheKey, responseJSON)
+	if h.words != nil {
+		h.words.SetLatestRevision(ctx, normalizedWord, langKey, revision.RevisionNumber)
+		h.cacheResponse(ctx, &response)
+	}
+
+	return &response, nil
+}
+
+// generateFirstRevision generates a new word's etymology and saves it as revision 1,
+// once per word and language: concurrent searches in this process share one run, and
+// searches on other replicas wait for it through a Redis lock.
+// The run outlives the request that started it while other searches wait on it, and
+// is cancelled when the last one leaves. Each waiter's onProgress (may be nil) is
+// called on the waiter's own goroutine with the LLM output as it is generated.
+func (h *WordHandler) generateFirstRevision(ctx context.Context, word *model.Word, normalizedWord, langKey string, generate generateFunc, onProgress func(text string)) (*model.WordWithEtymology, error) {
+	key := cache.CacheKey(normalizedWord, langKey)
+
+	var progress chan string
+	if onProgress != nil {
+		progress = make(chan string, progressBuffer)
+	}
+
+	h.generatingMu.Lock()
+	g, ok := h.generating[key]
+	if !ok {
+		genCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), h.llmTimeout)
+		g = &generation{done: make(chan struct{}), cancel: cancel, progress: make(map[chan string]struct{})}
+		h.generating[key] = g
+		go h.runGeneration(genCtx, key, g, word, normalizedWord, langKey, generate)
+	}
+	g.waiters++
+	if progress != nil {
+		if g.output.Len() > 0 {
+			progress <- g.output.String()
+		}
+		g.progress[progress] = struct{}{}
+	}
+	h.generatingMu.Unlock()
+	defer h.leaveGeneration(key, g, progress)
+
+	for {
+		select {
+		case text := <-progress:
+			onProgress(text)
+		case <-g.done:
+			return g.response, g.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
 		}
 	}
+}
 
-	c.JSON(http.StatusOK, response)
+// runGeneration runs g to completion and releases the waiters
+func (h *WordHandler) runGeneration(ctx context.Context, key string, g *generation, word *model.Word, normalizedWord, langKey string, generate generateFunc) {
+	defer g.cancel()
+
+	g.response, g.err = h.generateLocked(ctx, word, normalizedWord, langKey, func(ctx context.Context, _ func(string)) (*etymology.Document, client.PromptRef, error) {
+		return generate(ctx, func(text string) { h.publishProgress(g, text) })
+	})
+
+	h.generatingMu.Lock()
+	if h.generating[key] == g {
+		delete(h.generating, key)
+	}
+	h.generatingMu.Unlock()
+	close(g.done)
+}
+
+// publishProgress sends a chunk of LLM output to g's waiters. A waiter that has
+// fallen progressBuffer chunks behind misses it rather than stalling the others.
+func (h *WordHandler) publishProgress(g *generation, text string) {
+	h.generatingMu.Lock()
+	defer h.generatingMu.Unlock()
+	g.output.WriteString(text)
+	for ch := range g.progress {
+		select {
+		case ch <- text:
+		default:
+		}
+	}
+}
+
+// leaveGeneration unregisters a waiter from g, cancelling g when it was the last one.
+// A cancelled generation is forgotten at once so later searches start a fresh one.
+func (h *WordHandler) leaveGeneration(key string, g *generation, progress chan string) {
+	h.generatingMu.Lock()
+	defer h.generatingMu.Unlock()
+	if progress != nil {
+		delete(g.progress, progress)
+	}
+	g.waiters--
+	if g.waiters == 0 {
+		g.cancel()
+		if h.generating[key] == g {
+			delete(h.generating, key)
+		}
+	}
+}
+
+// generateLocked generates and saves while holding the word's generation lock. If another
+// replica holds it, it waits for that replica to save the word, or for the lock to expire.
+// Without Redis, or if the lock cannot be taken, it generates unlocked.
+func (h *WordHandler) generateLocked(ctx context.Context, word *model.Word, normalizedWord, langKey string, generate generateFunc) (*model.WordWithEtymology, error) {
+	if h.cache == nil {
+		return h.generateAndSave(ctx, word, normalizedWord, langKey, generate)
+	}
+
+	lockKey := cache.GenerateLockKey(normalizedWord, langKey)
+	deadline := time.Now().Add(h.generateLockTTL)
+	for {
+		lock, err := h.cache.AcquireLock(ctx, lockKey, h.generateLockTTL)
+		if err == nil {
+			defer lock.Release(context.WithoutCancel(ctx))
+			// The previous holder may have saved the word while we waited
+			response, existing := h.lookupSearchResult(ctx, 0, normalizedWord, langKey)
+			if response != nil {
+				return response, nil
+			}
+			if existing != nil {
+				word = existing
+			}
+			return h.generateAndSave(ctx, word, normalizedWord, langKey, generate)
+		}
+		if !errors.Is(err, cache.ErrLockHeld) {
+			log.Printf("Generation lock unavailable for %s, generating without it: %v", lockKey, err)
+			return h.generateAndSave(ctx, word, normalizedWord, langKey, generate)
+		}
+
+		if time.Now().After(deadline) {
+			return nil, context.DeadlineExceeded
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(generatePollInterval):
+		}
+		if response, _ := h.lookupSearchResult(ctx, 0, normalizedWord, langKey); response != nil {
+			log.Printf("Generated elsewhere: %s (language: %s)", normalizedWord, langKey)
+			return response, nil
+		}
+	}
+}
+
+// generateAndSave calls generate and stores its result as the word's first revision
+func (h *WordHandler) generateAndSave(ctx context.Context, word *model.Word, normalizedWord, langKey string, generate generateFunc) (*model.WordWithEtymology, error) {
+	doc, prompt, err := generate(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return h.saveSearchResult(ctx, word, normalizedWord, langKey, doc, prompt)
+}
+
+// llmErrorResponse maps an LLM proxy error to the status and body returned to the client.
+// message is used for failures the client can do nothing about.
+func llmErrorResponse(err error, message string) (int, gin.H) {
+	upstream, ok := client.AsUpstreamError(err)
+	if !ok {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return http.StatusGatewayTimeout, gin.H{"error": "The request took too long. Please try again.", "code": "LLM_TIMEOUT"}
+		}
+		return http.StatusInternalServerError, gin.H{"error": message}
+	}
+
+	switch {
+	case upstream.RateLimited():
+		body := gin.H{"error": "Rate limit exceeded. Please wait a moment.", "code": "RATE_LIMIT_EXCEEDED"}
+		if upstream.RetryAfter > 0 {
+			body["retryAfter"] = int(upstream.RetryAfter.Seconds())
+		}
+		return http.StatusTooManyRequests, body
+	case upstream.Code == client.CodeTimeout:
+		return http.StatusGatewayTimeout, gin.H{"error": "The request took too long. Please try again.", "code": "LLM_TIMEOUT"}
+	case upstream.Code == client.CodeContentBlocked:
+		return http.StatusUnprocessableEntity, gin.H{"error": "This word cannot be analyzed.", "code": "CONTENT_BLOCKED"}
+	case upstream.Code == client.CodeProviderUnavailable:
+		return http.StatusServiceUnavailable, gin.H{"error": "The etymology service is temporarily unavailable.", "code": "LLM_UNAVAILABLE"}
+	default:
+		return http.StatusInternalServerError, gin.H{"error": message}
+	}
+}
+
+// streamEvent writes one server-sent event and flushes it to the client
+func streamEvent(c *gin.Context, event string, data interface{}) {
+	c.SSEvent(event, data)
+	c.Writer.Flush()
 }
 
 func (h *WordHandler) GetEtymology(c *gin.Context) {
@@ -305,19 +750,23 @@ func (h *WordHandler) GetEtymology(c *gin.Context) {
 
 	if err != nil || revision == nil {
 		// No revision exists, fetch from LLM
-		etymology, err := h.llmClient.GetEtymologyWithLang(normalizedWord, language)
+		doc, prompt, err := h.llmClient.GetEtymologyWithLang(c.Request.Context(), normalizedWord, language)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch etymology"})
+			c.JSON(llmErrorResponse(err, "Failed to fetch etymology"))
 			return
 		}
 
-		etymologyJSON, _ := json.Marshal(etymology)
 		newRevision := model.EtymologyRevision{
 			WordID:         word.ID,
 			RevisionNumber: 1,
-			Etymology:      datatypes.JSON(etymologyJSON),
+			PromptID:       prompt.ID,
+			PromptVersion:  prompt.Version,
 			CreatedAt:      time.Now(),
 		}
+		if err := newRevision.SetDocument(doc); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch etymology"})
+			return
+		}
 		h.db.Create(&newRevision)
 		revision = &newRevision
 	}
@@ -354,31 +803,22 @@ func (h *WordHandler) GetDerivatives(c *gin.Context) {
 		return
 	}
 
-	// Extract derivatives from etymology JSON
-	var etymology map[string]interface{}
-	if err := json.Unmarshal(revision.Etymology, &etymology); err != nil {
+	// Extract derivatives from the typed etymology document
+	doc, err := revision.Document()
+	if err != nil {
+		log.Printf("Error decoding etymology for %s: %v", normalizedWord, err)
 		c.JSON(http.StatusOK, gin.H{
 			"word":        normalizedWord,
 			"language":    langKey,
-			"derivatives": []interface{}{},
+			"derivatives": []etymology.Derivative{},
 		})
 		return
 	}
 
-	// Handle nested language structure (etymology.ko, etymology.ja, etc.)
-	if langData, ok := etymology[langKey].(map[string]interface{}); ok {
-		etymology = langData
-	}
-
-	derivatives := etymology["derivatives"]
-	if derivatives == nil {
-		derivatives = []interface{}{}
-	}
-
 	c.JSON(http.StatusOK, gin.H{
 		"word":        normalizedWord,
 		"language":    langKey,
-		"derivatives": derivatives,
+		"derivatives": doc.Derivatives(),
 	})
 }
 
@@ -400,18 +840,10 @@ func (h *WordHandler) GetSynonyms(c *gin.Context) {
 	}
 
 	// Synonyms are fetched on-demand from LLM (not stored in DB)
-	synonymsData, err := h.llmClient.GetSynonyms(normalizedWord)
+	synonymsData, err := h.llmClient.GetSynonyms(c.Request.Context(), normalizedWord)
 	if err != nil {
 		log.Printf("Error fetching synonyms: %v", err)
-		errMsg := err.Error()
-		if strings.Contains(errMsg, "429") || strings.Contains(errMsg, "quota") || strings.Contains(errMsg, "RESOURCE_EXHAUSTED") {
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error": "Rate limit exceeded. Please wait a moment.",
-				"code":  "RATE_LIMIT_EXCEEDED",
-			})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch synonyms"})
+		c.JSON(llmErrorResponse(err, "Failed to fetch synonyms"))
 		return
 	}
 
@@ -451,23 +883,13 @@ func (h *WordHandler) RefreshEtymology(c *gin.Context) {
 	}
 
 	log.Printf("Refreshing etymology for: %s (language: %s)", normalizedWord, language)
-	newEtymology, err := h.llmClient.GetEtymologyWithLang(normalizedWord, language)
+	doc, prompt, err := h.llmClient.GetEtymologyWithLang(c.Request.Context(), normalizedWord, language)
 	if err != nil {
 		log.Printf("Error fetching etymology: %v", err)
-		errMsg := err.Error()
-		if strings.Contains(errMsg, "429") || strings.Contains(errMsg, "quota") || strings.Contains(errMsg, "RESOURCE_EXHAUSTED") {
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error": "Rate limit exceeded. Please wait a moment.",
-				"code":  "RATE_LIMIT_EXCEEDED",
-			})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch etymology"})
+		c.JSON(llmErrorResponse(err, "Failed to fetch etymology"))
 		return
 	}
 
-	newEtymologyJSON, _ := json.Marshal(newEtymology)
-
 	// Get the highest revision number
 	var maxRevision int
 	h.db.Model(&model.EtymologyRevision{}).Where("word_id = ?", word.ID).
@@ -479,9 +901,15 @@ func (h *WordHandler) RefreshEtymology(c *gin.Context) {
 	newRevision := model.EtymologyRevision{
 		WordID:         word.ID,
 		RevisionNumber: newRevisionNumber,
-		Etymology:      datatypes.JSON(newEtymologyJSON),
+		PromptID:       prompt.ID,
+		PromptVersion:  prompt.Version,
 		CreatedAt:      time.Now(),
 	}
+	if err := newRevision.SetDocument(doc); err != nil {
+		log.Printf("Error encoding etymology for %s: %v", normalizedWord, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create revision"})
+		return
+	}
 	if err := h.db.Create(&newRevision).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create revision"})
 		return
@@ -499,11 +927,14 @@ func (h *WordHandler) RefreshEtymology(c *gin.Context) {
 		h.db.Create(&pref)
 	}
 
-	// Invalidate Redis cache
-	cacheKey := cache.CacheKey(normalizedWord, langKey)
-	if h.cache != nil {
-		h.cache.Delete(c.Request.Context(), cacheKey)
-		log.Printf("Redis cache invalidated: %s", cacheKey)
+	// Invalidate Redis cache: every cached revision lists the revisions, so all are stale
+	if h.words != nil {
+		ctx := c.Request.Context()
+		h.words.InvalidateWord(ctx, normalizedWord, langKey, newRevisionNumber)
+		if userID, exists := c.Get("userID"); exists {
+			h.words.DeletePreference(ctx, userID.(int64), normalizedWord, langKey)
+		}
+		log.Printf("Redis cache invalidated: %s", cache.CacheKey(normalizedWord, langKey))
 	}
 
 	response := h.buildWordResponse(&word, &newRevision, true)
@@ -623,15 +1054,21 @@ func (h *WordHandler) SelectRevision(c *gin.Context) {
 		})
 	}
 
+	// Invalidate the cached preference so Search resolves the new one
+	if h.words != nil {
+		h.words.DeletePreference(c.Request.Context(), userID.(int64), normalizedWord, langKey)
+	}
+
 	response := h.buildWordResponse(&word, &revision, true)
 	c.JSON(http.StatusOK, response)
 }
 
-// Suggest returns word suggestions for autocomplete based on prefix
+// Suggest returns word suggestions for autocomplete: prefix matches ("priority", then
+// "general"), then "corrections" within a small edit distance to fill the remaining slots
 func (h *WordHandler) Suggest(c *gin.Context) {
 	query := strings.ToLower(strings.TrimSpace(c.Query("q")))
 	if len(query) < 2 {
-		c.JSON(http.StatusOK, gin.H{"suggestions": gin.H{"priority": []string{}, "general": []string{}}})
+		c.JSON(http.StatusOK, gin.H{"suggestions": gin.H{"priority": []string{}, "general": []string{}, "corrections": []string{}}})
 		return
 	}
 
@@ -642,8 +1079,15 @@ func (h *WordHandler) Suggest(c *gin.Context) {
 		}
 	}
 
-	if h.cache == nil {
-		c.JSON(http.StatusOK, gin.H{"suggestions": gin.H{"priority": []string{}, "general": []string{}}})
+	if h.cache == nil && h.suggester == nil {
+		c.JSON(http.StatusOK, gin.H{"suggestions": gin.H{"priority": []string{}, "general": []string{}, "corrections": []string{}}})
+		return
+	}
+
+	// Serve repeated prefixes from memory; the autocomplete sets only change on startup
+	suggestKey := query + ":" + strconv.Itoa(limit)
+	if cached, ok := h.suggestions.Get(suggestKey); ok {
+		c.Data(http.StatusOK, "application/json; charset=utf-8", cached)
 		return
 	}
 
@@ -651,10 +1095,28 @@ func (h *WordHandler) Suggest(c *gin.Context) {
 
 	// Get priority suggestions (max 3)
 	priorityLimit := 3
-	prioritySuggestions, err := h.cache.GetPrioritySuggestions(ctx, query, priorityLimit)
-	if err != nil {
-		log.Printf("Redis priority suggest error: %v", err)
-		prioritySuggestions = []string{}
+	cacheable := true // not if Redis failed and the lists are incomplete
+	prioritySuggestions := []string{}
+	generalSuggestions := []string{}
+	if h.cache != nil {
+		var err error
+		prioritySuggestions, err = h.cache.GetPrioritySuggestions(ctx, query, priorityLimit)
+		if err != nil {
+			log.Printf("Redis priority suggest error: %v", err)
+			prioritySuggestions = []string{}
+			cacheable = false
+		}
+
+		// Get general suggestions
+		generalSuggestions, err = h.cache.GetSuggestions(ctx, query, limit+priorityLimit)
+		if err != nil {
+			log.Printf("Redis suggest error: %v", err)
+			generalSuggestions = []string{}
+			cacheable = false
+		}
+	} else {
+		// Without Redis, prefix matches come from the in-memory word list
+		generalSuggestions = h.suggester.Prefix(query, limit)
 	}
 
 	// Create a set of priority words for deduplication
@@ -663,13 +1125,6 @@ func (h *WordHandler) Suggest(c *gin.Context) {
 		prioritySet[word] = true
 	}
 
-	// Get general suggestions
-	generalSuggestions, err := h.cache.GetSuggestions(ctx, query, limit+priorityLimit)
-	if err != nil {
-		log.Printf("Redis suggest error: %v", err)
-		generalSuggestions = []string{}
-	}
-
 	// Filter out priority words from general suggestions
 	filteredGeneral := make([]string, 0, len(generalSuggestions))
 	for _, word := range generalSuggestions {
@@ -683,27 +1138,53 @@ func (h *WordHandler) Suggest(c *gin.Context) {
 		filteredGeneral = filteredGeneral[:limit-len(prioritySuggestions)]
 	}
 
-	c.JSON(http.StatusOK, gin.H{"suggestions": gin.H{
-		"priority": prioritySuggestions,
-		"general":  filteredGeneral,
+	// Fill the remaining slots with typo corrections ("recieve" -> "receive")
+	corrections := []string{}
+	if remaining := limit - len(prioritySuggestions) - len(filteredGeneral); remaining > 0 && h.suggester != nil {
+		listed := make(map[string]bool, len(prioritySet)+len(filteredGeneral))
+		for word := range prioritySet {
+			listed[word] = true
+		}
+		for _, word := range filteredGeneral {
+			listed[word] = true
+		}
+		for _, word := range h.suggester.Corrections(query, remaining+len(listed)) {
+			if !listed[word] && len(corrections) < remaining {
+				corrections = append(corrections, word)
+			}
+		}
+	}
+
+	response, err := json.Marshal(gin.H{"suggestions": gin.H{
+		"priority":    prioritySuggestions,
+		"general":     filteredGeneral,
+		"corrections": corrections,
 	}})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode suggestions"})
+		return
+	}
+	if cacheable {
+		h.suggestions.Set(suggestKey, response, 0)
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", response)
 }
 
 // filterDerivativesInPlace removes grammatical variations of the input word from etymology derivatives.
-func filterDerivativesInPlace(word string, etymology map[string]interface{}) {
-	if etymology == nil {
+func filterDerivativesInPlace(word string, doc *etymology.Document) {
+	if doc == nil || doc.Kind != etymology.KindWord {
 		return
 	}
 
-	// Check if derivatives exist at top level
-	if derivatives, ok := etymology["derivatives"].([]interface{}); ok {
-		etymology["derivatives"] = filter.FilterDerivatives(word, derivatives)
-	}
+	doc.Word.Derivatives = filter.FilterDerivatives(word, doc.Word.Derivatives)
 }
 
 // saveSearchHistory saves a search to Redis history buffer
 // The buffer will be flushed to DB periodically by a CronJob
 func (h *WordHandler) saveSearchHistory(userID int64, word string, language string) {
+	if h.cache == nil {
+		return
+	}
 	if language == "" {
 		language = "Korean"
 	}
@@ -0,0 +1,390 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/etymograph/api/internal/cache"
+	"github.com/etymograph/api/internal/config"
+	"github.com/etymograph/api/internal/model"
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const (
+	userA int64 = 1 // prefers revision 1
+	userB int64 = 2 // prefers revision 2
+)
+
+// fakeStore is an in-memory cache.Store that records which keys were read and deleted
+type fakeStore struct {
+	mu      sync.Mutex
+	values  map[string][]byte
+	hits    []string
+	deleted []string
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{values: make(map[string][]byte)}
+}
+
+func (s *fakeStore) Get(ctx context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, ok := s.values[key]
+	if !ok {
+		return nil, cache.ErrMiss
+	}
+	s.hits = append(s.hits, key)
+	return value, nil
+}
+
+func (s *fakeStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[key] = value
+	return nil
+}
+
+func (s *fakeStore) Delete(ctx context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range keys {
+		delete(s.values, key)
+		s.deleted = append(s.deleted, key)
+	}
+	return nil
+}
+
+func (s *fakeStore) has(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.values[key]
+	return ok
+}
+
+func (s *fakeStore) wasHit(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, hit := range s.hits {
+		if hit == key {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *fakeStore) wasDeleted(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, d := range s.deleted {
+		if d == key {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *fakeStore) resetLog() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hits, s.deleted = nil, nil
+}
+
+// newTestDB opens an in-memory SQLite database with the word tables
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("open test db: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("open test db: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.AutoMigrate(
+		&model.Word{},
+		&model.EtymologyRevision{},
+		&model.UserEtymologyPreference{},
+		&model.WordRoot{},
+		&model.WordMorpheme{},
+	); err != nil {
+		t.Fatalf("migrate test db: %v", err)
+	}
+	return db
+}
+
+// testEtymology is a minimal valid word etymology whose brief definition names its revision
+func testEtymology(word, brief string) []byte {
+	doc, _ := json.Marshal(gin.H{
+		"word":       word,
+		"definition": gin.H{"brief": brief},
+		"origin":     gin.H{"language": "Latin", "root": "docere"},
+		"evolution":  "teach + -er",
+	})
+	return doc
+}
+
+// seedRevisions stores "teacher" with revisions 1 and 2, and each test user's preference
+func seedRevisions(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	word := model.Word{Word: "teacher", Language: "ko"}
+	if err := db.Create(&word).Error; err != nil {
+		t.Fatalf("seed word: %v", err)
+	}
+
+	revisions := make(map[int]int64)
+	for _, n := range []int{1, 2} {
+		rev := model.EtymologyRevision{
+			WordID:         word.ID,
+			RevisionNumber: n,
+			Etymology:      datatypes.JSON(testEtymology("teacher", "r"+strconv.Itoa(n))),
+			CreatedAt:      time.Now(),
+		}
+		if err := db.Create(&rev).Error; err != nil {
+			t.Fatalf("seed revision %d: %v", n, err)
+		}
+		revisions[n] = rev.ID
+	}
+
+	for userID, n := range map[int64]int{userA: 1, userB: 2} {
+		pref := model.UserEtymologyPreference{UserID: userID, WordID: word.ID, RevisionID: revisions[n], UpdatedAt: time.Now()}
+		if err := db.Create(&pref).Error; err != nil {
+			t.Fatalf("seed preference: %v", err)
+		}
+	}
+}
+
+// newTestRouter serves the word routes; requests authenticate with an X-Test-User header.
+// llmProxyURL may be empty for tests that never reach the LLM.
+func newTestRouter(db *gorm.DB, store cache.Store, llmProxyURL string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{
+		LLMProxyURL:       llmProxyURL,
+		LLMTimeout:        5 * time.Second,
+		CacheL1MaxEntries: 100,
+		CacheL1MaxBytes:   1 << 20,
+		CacheL1TTL:        time.Minute,
+	}
+	h := NewWordHandler(db, nil, cache.NewWordCache(store, nil, cache.WordCacheOptions{}), cfg, nil, nil)
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if id, err := strconv.ParseInt(c.GetHeader("X-Test-User"), 10, 64); err == nil {
+			c.Set("userID", id)
+		}
+	})
+	r.POST("/api/words/search", h.Search)
+	r.POST("/api/words/:word/refresh", h.RefreshEtymology)
+	r.POST("/api/words/:word/revisions/:revNum/select", h.SelectRevision)
+	return r
+}
+
+func serve(t *testing.T, r *gin.Engine, method, path string, userID int64, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	var reqBody bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&reqBody).Encode(body)
+	}
+	req := httptest.NewRequest(method, path, &reqBody)
+	req.Header.Set("Content-Type", "application/json")
+	if userID != 0 {
+		req.Header.Set("X-Test-User", strconv.FormatInt(userID, 10))
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// searchRevision searches "teacher" as userID (0 = anonymous) and returns the revision served
+func searchRevision(t *testing.T, r *gin.Engine, userID int64) int {
+	t.Helper()
+	w := serve(t, r, http.MethodPost, "/api/words/search", userID, gin.H{"word": "teacher"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("search as user %d: status %d: %s", userID, w.Code, w.Body.String())
+	}
+	var response model.WordWithEtymology
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("search as user %d: %v", userID, err)
+	}
+	var doc struct {
+		Definition struct {
+			Brief string `json:"brief"`
+		} `json:"definition"`
+	}
+	json.Unmarshal(response.Etymology, &doc)
+	if want := "r" + strconv.Itoa(response.CurrentRevision); doc.Definition.Brief != want {
+		t.Errorf("user %d got revision %d with etymology %q", userID, response.CurrentRevision, doc.Definition.Brief)
+	}
+	return response.CurrentRevision
+}
+
+func TestSearchServesEachUsersRevision(t *testing.T) {
+	db := newTestDB(t)
+	seedRevisions(t, db)
+	store := newFakeStore()
+	r := newTestRouter(db, store, "")
+
+	want := map[int64]int{userA: 1, userB: 2, 0: 2}
+
+	// Cache miss: resolved from the database, then cached
+	for userID, rev := range want {
+		if got := searchRevision(t, r, userID); got != rev {
+			t.Errorf("miss: user %d got revision %d, want %d", userID, got, rev)
+		}
+	}
+	for _, key := range []string{
+		"cache:v1:word:teacher:ko:r1",
+		"cache:v1:word:teacher:ko:r2",
+		"cache:v1:word:teacher:ko:latest",
+		"cache:v1:pref:1:teacher:ko",
+		"cache:v1:pref:2:teacher:ko",
+	} {
+		if !store.has(key) {
+			t.Errorf("miss did not cache %s", key)
+		}
+	}
+
+	// Cache hit: each user's revision is read from the cache
+	store.resetLog()
+	for userID, rev := range want {
+		if got := searchRevision(t, r, userID); got != rev {
+			t.Errorf("hit: user %d got revision %d, want %d", userID, got, rev)
+		}
+	}
+	for _, key := range []string{"cache:v1:word:teacher:ko:r1", "cache:v1:word:teacher:ko:r2"} {
+		if !store.wasHit(key) {
+			t.Errorf("hit did not read %s from the cache", key)
+		}
+	}
+}
+
+func TestSelectRevisionInvalidatesPreference(t *testing.T) {
+	db := newTestDB(t)
+	seedRevisions(t, db)
+	store := newFakeStore()
+	r := newTestRouter(db, store, "")
+
+	if got := searchRevision(t, r, userA); got != 1 {
+		t.Fatalf("user A got revision %d before selecting, want 1", got)
+	}
+
+	store.resetLog()
+	if w := serve(t, r, http.MethodPost, "/api/words/teacher/revisions/2/select", userA, nil); w.Code != http.StatusOK {
+		t.Fatalf("select: status %d: %s", w.Code, w.Body.String())
+	}
+	if !store.wasDeleted("cache:v1:pref:1:teacher:ko") {
+		t.Error("select did not invalidate user A's cached preference")
+	}
+	if store.wasDeleted("cache:v1:pref:2:teacher:ko") {
+		t.Error("select invalidated another user's cached preference")
+	}
+
+	if got := searchRevision(t, r, userA); got != 2 {
+		t.Errorf("user A got revision %d after selecting 2", got)
+	}
+	if got := searchRevision(t, r, userB); got != 2 {
+		t.Errorf("user B got revision %d, want 2", got)
+	}
+}
+
+func TestRefreshEtymologyInvalidatesPreference(t *testing.T) {
+	llmProxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/etymology" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(testEtymology("teacher", "r3"))
+	}))
+	defer llmProxy.Close()
+
+	db := newTestDB(t)
+	seedRevisions(t, db)
+	store := newFakeStore()
+	r := newTestRouter(db, store, llmProxy.URL)
+
+	for userID, rev := range map[int64]int{userA: 1, userB: 2} {
+		if got := searchRevision(t, r, userID); got != rev {
+			t.Fatalf("user %d got revision %d before refresh, want %d", userID, got, rev)
+		}
+	}
+
+	store.resetLog()
+	if w := serve(t, r, http.MethodPost, "/api/words/teacher/refresh", userB, nil); w.Code != http.StatusOK {
+		t.Fatalf("refresh: status %d: %s", w.Code, w.Body.String())
+	}
+	if !store.wasDeleted("cache:v1:pref:2:teacher:ko") {
+		t.Error("refresh did not invalidate user B's cached preference")
+	}
+	for _, key := range []string{"cache:v1:word:teacher:ko:r1", "cache:v1:word:teacher:ko:r2", "cache:v1:word:teacher:ko:latest"} {
+		if store.has(key) {
+			t.Errorf("refresh left %s cached", key)
+		}
+	}
+
+	if got := searchRevision(t, r, userB); got != 3 {
+		t.Errorf("user B got revision %d after refreshing, want 3", got)
+	}
+	if got := searchRevision(t, r, userA); got != 1 {
+		t.Errorf("user A got revision %d after user B refreshed, want 1", got)
+	}
+	if got := searchRevision(t, r, 0); got != 3 {
+		t.Errorf("anonymous user got revision %d after refresh, want 3", got)
+	}
+}
+
+func TestSearchCancelsGenerationWhenLastWaiterLeaves(t *testing.T) {
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+	llmProxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body) // the server notices disconnects once the body is read
+		close(started)
+		<-r.Context().Done()
+		close(cancelled)
+	}))
+	defer llmProxy.Close()
+
+	db := newTestDB(t)
+	r := newTestRouter(db, newFakeStore(), llmProxy.URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodPost, "/api/words/search", bytes.NewBufferString(`{"word":"teacher"}`)).WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	served := make(chan struct{})
+	go func() {
+		r.ServeHTTP(httptest.NewRecorder(), req)
+		close(served)
+	}()
+
+	select {
+	case <-started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("search did not reach the LLM proxy")
+	}
+	cancel()
+
+	for name, ch := range map[string]chan struct{}{"search": served, "LLM call": cancelled} {
+		select {
+		case <-ch:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("%s kept running after its only client left", name)
+		}
+	}
+}
@@ -55,6 +55,15 @@ var (
 		[]string{"status"},
 	)
 
+	// Rate limiter 판정 결과 수
+	rateLimitDecisionsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rate_limit_decisions_total",
+			Help: "Total number of rate limit decisions",
+		},
+		[]string{"action", "result"},
+	)
+
 	// LLM 프록시 응답 시간
 	llmProxyDuration = promauto.NewHistogram(
 		prometheus.HistogramOpts{
@@ -122,3 +131,8 @@ func RecordLLMProxyCall(success bool, duration time.Duration) {
 	llmProxyCallsTotal.WithLabelValues(status).Inc()
 	llmProxyDuration.Observe(duration.Seconds())
 }
+
+// RecordRateLimitDecision은 rate limiter 판정 결과(allowed, denied, error)를 기록합니다.
+func RecordRateLimitDecision(action, result string) {
+	rateLimitDecisionsTotal.WithLabelValues(action, result).Inc()
+}
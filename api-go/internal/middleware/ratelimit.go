@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/etymograph/api/internal/auth"
+	"github.com/etymograph/api/internal/client"
+	"github.com/gin-gonic/gin"
+)
+
+// RouteActions maps "METHOD path" (as registered in the router) to rate-limiter actions.
+// Routes not listed here are not rate limited.
+var RouteActions = map[string]string{
+	"POST /api/words/search":           "search",
+	"POST /api/words/search/stream":    "search",
+	"GET /api/words/:word/etymology":   "etymology",
+	"POST /api/words/:word/refresh":    "etymology",
+	"GET /api/words/:word/derivatives": "derivatives",
+	"GET /api/words/:word/synonyms":    "synonyms",
+	"GET /api/export/:sessionId":       "export",
+}
+
+// RateLimitConfig configures RateLimitMiddleware
+type RateLimitConfig struct {
+	Client      client.RateLimiter
+	JWTSecret   string
+	AdminEmails []string
+	// FailOpen lets requests through when the rate-limiter is unreachable;
+	// when false they are rejected with 503
+	FailOpen bool
+	// Timeout bounds each check (DefaultRateLimitTimeout if zero)
+	Timeout time.Duration
+}
+
+// DefaultRateLimitTimeout bounds a rate limit check when RateLimitConfig.Timeout is unset
+const DefaultRateLimitTimeout = 500 * time.Millisecond
+
+// RateLimitMiddleware checks each mapped route against the rate-limiter service.
+// Clients are keyed by user ID when a valid access token is present, otherwise by IP,
+// and sent with their policy tier (anonymous, user or admin).
+func RateLimitMiddleware(cfg RateLimitConfig) gin.HandlerFunc {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultRateLimitTimeout
+	}
+	return func(c *gin.Context) {
+		action, ok := RouteActions[c.Request.Method+" "+c.FullPath()]
+		if !ok {
+			c.Next()
+			return
+		}
+
+		clientID, tier := rateLimitClient(c, cfg.JWTSecret, cfg.AdminEmails)
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), cfg.Timeout)
+		result, err := cfg.Client.Check(ctx, clientID, tier, action)
+		cancel()
+
+		if err != nil {
+			log.Printf("Rate limiter check failed for %s/%s: %v", clientID, action, err)
+			RecordRateLimitDecision(action, "error")
+			if cfg.FailOpen {
+				c.Next()
+				return
+			}
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error": "Rate limiter unavailable. Please try again later.",
+				"code":  "RATE_LIMITER_UNAVAILABLE",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.FormatInt(result.Limit, 10))
+		c.Header("X-RateLimit-Remaining", strconv.FormatInt(result.Remaining, 10))
+		c.Header("X-RateLimit-Reset", strconv.FormatInt(result.ResetAt, 10))
+
+		if !result.Allowed {
+			retryAfter := result.ResetAt - time.Now().Unix()
+			if retryAfter < 1 {
+				retryAfter = 1
+			}
+			c.Header("Retry-After", strconv.FormatInt(retryAfter, 10))
+			RecordRateLimitDecision(action, "denied")
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error": "Rate limit exceeded. Please wait a moment.",
+				"code":  "RATE_LIMIT_EXCEEDED",
+			})
+			c.Abort()
+			return
+		}
+
+		RecordRateLimitDecision(action, "allowed")
+		c.Next()
+	}
+}
+
+// rateLimitClient returns ("user:<id>", "user" or "admin") for a valid bearer token,
+// otherwise ("ip:<client IP>", "anonymous").
+// Route-level auth middleware runs after this, so the token is parsed here directly.
+func rateLimitClient(c *gin.Context, jwtSecret string, adminEmails []string) (string, string) {
+	parts := strings.SplitN(c.GetHeader("Authorization"), " ", 2)
+	if len(parts) == 2 && strings.ToLower(parts[0]) == "bearer" {
+		if claims, err := auth.ValidateAccessToken(parts[1], jwtSecret); err == nil {
+			tier := "user"
+			for _, email := range adminEmails {
+				if strings.EqualFold(email, claims.Email) {
+					tier = "admin"
+					break
+				}
+			}
+			return "user:" + strconv.FormatInt(claims.UserID, 10), tier
+		}
+	}
+	return "ip:" + c.ClientIP(), "anonymous"
+}
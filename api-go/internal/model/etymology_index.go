@@ -0,0 +1,100 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WordRoot indexes the origin root of an etymology revision so that words
+// sharing a root (videre → view, review, interview) can be looked up directly.
+// Every revision is indexed; lookups only match a word's latest revision.
+// Note: Unique index on (revision_id, root) is created in migration
+type WordRoot struct {
+	ID             int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	WordID         int64     `gorm:"not null" json:"wordId"`
+	RevisionID     int64     `gorm:"not null" json:"revisionId"`
+	Root           string    `gorm:"not null;size:255" json:"root"`
+	OriginLanguage string    `gorm:"size:100" json:"originLanguage"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+func (WordRoot) TableName() string {
+	return "word_roots"
+}
+
+// WordMorpheme indexes each origin component (pre-, script-, -tion) of an etymology revision
+// Note: Unique index on (revision_id, part, position) is created in migration
+type WordMorpheme struct {
+	ID         int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	WordID     int64     `gorm:"not null" json:"wordId"`
+	RevisionID int64     `gorm:"not null" json:"revisionId"`
+	Part       string    `gorm:"not null;size:255" json:"part"`
+	Position   int       `gorm:"not null" json:"position"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+func (WordMorpheme) TableName() string {
+	return "word_morphemes"
+}
+
+// NormalizeMorpheme normalizes a root or component spelling for indexing and lookup
+func NormalizeMorpheme(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
+
+// IndexEntries builds the root and morpheme index rows for a revision.
+// Revisions that cannot be decoded or are affixes produce no entries.
+func (r *EtymologyRevision) IndexEntries() ([]WordRoot, []WordMorpheme) {
+	doc, err := r.Document()
+	if err != nil || doc.Word == nil {
+		return nil, nil
+	}
+
+	var roots []WordRoot
+	if root := NormalizeMorpheme(doc.Word.Origin.Root); root != "" {
+		roots = append(roots, WordRoot{
+			WordID:         r.WordID,
+			RevisionID:     r.ID,
+			Root:           root,
+			OriginLanguage: strings.TrimSpace(doc.Word.Origin.Language),
+		})
+	}
+
+	var morphemes []WordMorpheme
+	seen := make(map[string]bool)
+	for i, comp := range doc.Word.Origin.Components {
+		part := NormalizeMorpheme(comp.Part)
+		if part == "" || seen[part] {
+			continue
+		}
+		seen[part] = true
+		morphemes = append(morphemes, WordMorpheme{
+			WordID:     r.WordID,
+			RevisionID: r.ID,
+			Part:       part,
+			Position:   i,
+		})
+	}
+
+	return roots, morphemes
+}
+
+// AfterCreate keeps the root and morpheme index in sync with every new revision.
+// Rows the backfill already wrote for the revision are left as they are.
+func (r *EtymologyRevision) AfterCreate(tx *gorm.DB) error {
+	roots, morphemes := r.IndexEntries()
+	if len(roots) > 0 {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&roots).Error; err != nil {
+			return err
+		}
+	}
+	if len(morphemes) > 0 {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&morphemes).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package model
 import (
 	"time"
 
+	"github.com/etymograph/api/internal/etymology"
 	"gorm.io/datatypes"
 )
 
@@ -26,13 +27,32 @@ type EtymologyRevision struct {
 	WordID         int64          `gorm:"not null" json:"wordId"`
 	RevisionNumber int            `gorm:"not null" json:"revisionNumber"`
 	Etymology      datatypes.JSON `gorm:"not null" json:"etymology"`
-	CreatedAt      time.Time      `json:"createdAt"`
+	// PromptID and PromptVersion identify the llm-proxy prompt template that
+	// generated this revision (empty for revisions created before prompts were versioned)
+	PromptID      string    `gorm:"size:64" json:"promptId,omitempty"`
+	PromptVersion int       `json:"promptVersion,omitempty"`
+	CreatedAt     time.Time `json:"createdAt"`
 }
 
 func (EtymologyRevision) TableName() string {
 	return "etymology_revisions"
 }
 
+// Document decodes the stored etymology into its typed form
+func (r *EtymologyRevision) Document() (*etymology.Document, error) {
+	return etymology.Decode(r.Etymology)
+}
+
+// SetDocument validates and stores a typed etymology document
+func (r *EtymologyRevision) SetDocument(doc *etymology.Document) error {
+	data, err := etymology.Encode(doc)
+	if err != nil {
+		return err
+	}
+	r.Etymology = datatypes.JSON(data)
+	return nil
+}
+
 // UserEtymologyPreference stores user's preferred revision for a word
 type UserEtymologyPreference struct {
 	ID         int64     `gorm:"primaryKey;autoIncrement" json:"id"`
@@ -62,5 +82,7 @@ type WordWithEtymology struct {
 // RevisionSummary provides a brief overview of a revision
 type RevisionSummary struct {
 	RevisionNumber int       `json:"revisionNumber"`
+	PromptID       string    `json:"promptId,omitempty"`
+	PromptVersion  int       `json:"promptVersion,omitempty"`
 	CreatedAt      time.Time `json:"createdAt"`
 }
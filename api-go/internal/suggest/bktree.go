@@ -0,0 +1,115 @@
+package suggest
+
+// bkNode is a node of a BK-tree: every word in the subtree of children[d]
+// is at Levenshtein distance d from word
+type bkNode struct {
+	word     string
+	children []bkEdge
+}
+
+type bkEdge struct {
+	distance int
+	node     *bkNode
+}
+
+func (n *bkNode) insert(word string) {
+	for {
+		d := levenshtein(n.word, word)
+		if d == 0 {
+			return
+		}
+		next := n.child(d)
+		if next == nil {
+			n.children = append(n.children, bkEdge{distance: d, node: &bkNode{word: word}})
+			return
+		}
+		n = next
+	}
+}
+
+func (n *bkNode) child(distance int) *bkNode {
+	for _, edge := range n.children {
+		if edge.distance == distance {
+			return edge.node
+		}
+	}
+	return nil
+}
+
+// search calls visit with every word within maxDistance of query. By the triangle
+// inequality only children within maxDistance of the node's own distance can match.
+func (n *bkNode) search(query string, maxDistance int, visit func(word string)) {
+	q := []rune(query)
+	prev, curr := make([]int, len(q)+1), make([]int, len(q)+1)
+	stack := []*bkNode{n}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		d := levenshteinRunes(node.word, q, prev, curr)
+		if d <= maxDistance {
+			visit(node.word)
+		}
+		for _, edge := range node.children {
+			if edge.distance >= d-maxDistance && edge.distance <= d+maxDistance {
+				stack = append(stack, edge.node)
+			}
+		}
+	}
+}
+
+// levenshtein returns the number of single-rune insertions, deletions and
+// substitutions turning a into b
+func levenshtein(a, b string) int {
+	rb := []rune(b)
+	return levenshteinRunes(a, rb, make([]int, len(rb)+1), make([]int, len(rb)+1))
+}
+
+// levenshteinRunes is levenshtein over a pre-split b, using prev and curr (both
+// len(b)+1) as scratch rows so searches do not allocate per node
+func levenshteinRunes(a string, b []rune, prev, curr []int) int {
+	for j := range prev {
+		prev[j] = j
+	}
+	i := 0
+	for _, r := range a {
+		i++
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if r == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// osaDistance is levenshtein that also counts swapping two adjacent runes as one
+// edit (optimal string alignment), so "recieve" is one edit from "receive"
+func osaDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
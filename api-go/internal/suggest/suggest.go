@@ -0,0 +1,187 @@
+package suggest
+
+import (
+	"bufio"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Index suggests dictionary words for a query: words starting with it, and
+// corrections within a small edit distance for misspellings ("recieve" → "receive")
+type Index struct {
+	words    []string        // sorted, for prefix matches
+	trees    map[int]*bkNode // Levenshtein BK-trees over words by length in runes, for corrections
+	priority map[string]struct{}
+}
+
+// Load builds an index from a word list and a list of priority words, which rank
+// first among corrections at the same distance. The priority list is optional.
+func Load(wordListPath, priorityPath string) (*Index, error) {
+	words, err := readWords(wordListPath)
+	if err != nil {
+		return nil, err
+	}
+	priority, err := readWords(priorityPath)
+	if err != nil {
+		log.Printf("Warning: failed to load priority words for suggestions: %v", err)
+	}
+
+	index := New(words, priority)
+	log.Printf("Loaded %d words into suggestion index", len(index.words))
+	return index, nil
+}
+
+// New builds an index over words
+func New(words, priority []string) *Index {
+	index := &Index{trees: make(map[int]*bkNode), priority: make(map[string]struct{}, len(priority))}
+	for _, word := range priority {
+		index.priority[word] = struct{}{}
+	}
+
+	seen := make(map[string]struct{}, len(words))
+	for _, word := range words {
+		if _, dup := seen[word]; dup {
+			continue
+		}
+		seen[word] = struct{}{}
+		index.words = append(index.words, word)
+		n := len([]rune(word))
+		if tree := index.trees[n]; tree == nil {
+			index.trees[n] = &bkNode{word: word}
+		} else {
+			tree.insert(word)
+		}
+	}
+	sort.Strings(index.words)
+	return index
+}
+
+func readWords(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if word := strings.ToLower(strings.TrimSpace(scanner.Text())); word != "" {
+			words = append(words, word)
+		}
+	}
+	return words, scanner.Err()
+}
+
+// Prefix returns up to limit words starting with prefix, in lexicographic order
+func (i *Index) Prefix(prefix string, limit int) []string {
+	prefix = strings.ToLower(prefix)
+	start := sort.SearchStrings(i.words, prefix)
+
+	var matches []string
+	for _, word := range i.words[start:] {
+		if len(matches) >= limit || !strings.HasPrefix(word, prefix) {
+			break
+		}
+		matches = append(matches, word)
+	}
+	return matches
+}
+
+// Corrections returns up to limit words other than query within its edit distance
+// budget (see MaxDistance), closest first. A single pair of transposed letters counts
+// as one edit, and priority words rank first among equally close words.
+func (i *Index) Corrections(query string, limit int) []string {
+	query = strings.ToLower(query)
+	maxDistance := MaxDistance(query)
+	if maxDistance == 0 || limit <= 0 {
+		return nil
+	}
+
+	type candidate struct {
+		word     string
+		distance int
+		priority bool
+	}
+	var candidates []candidate
+	seen := make(map[string]struct{})
+	add := func(word string) {
+		if _, dup := seen[word]; dup || word == query {
+			return
+		}
+		seen[word] = struct{}{}
+		if d := osaDistance(query, word); d <= maxDistance {
+			_, priority := i.priority[word]
+			candidates = append(candidates, candidate{word: word, distance: d, priority: priority})
+		}
+	}
+
+	// A word within maxDistance edits differs in length by at most maxDistance, so only
+	// those trees are searched
+	n := len([]rune(query))
+	for length := n - maxDistance; length <= n+maxDistance; length++ {
+		if tree := i.trees[length]; tree != nil {
+			tree.search(query, maxDistance, add)
+		}
+	}
+	// Levenshtein counts a transposition as two edits, so single swaps the trees can
+	// miss are looked up directly
+	runes := []rune(query)
+	for j := 0; j+1 < len(runes); j++ {
+		if runes[j] == runes[j+1] {
+			continue
+		}
+		runes[j], runes[j+1] = runes[j+1], runes[j]
+		if word := string(runes); i.contains(word) {
+			add(word)
+		}
+		runes[j], runes[j+1] = runes[j+1], runes[j]
+	}
+
+	sort.Slice(candidates, func(a, b int) bool {
+		ca, cb := candidates[a], candidates[b]
+		if ca.distance != cb.distance {
+			return ca.distance < cb.distance
+		}
+		if ca.priority != cb.priority {
+			return ca.priority
+		}
+		if la, lb := abs(len(ca.word)-len(query)), abs(len(cb.word)-len(query)); la != lb {
+			return la < lb
+		}
+		return ca.word < cb.word
+	})
+
+	corrections := make([]string, 0, min(limit, len(candidates)))
+	for _, c := range candidates[:min(limit, len(candidates))] {
+		corrections = append(corrections, c.word)
+	}
+	return corrections
+}
+
+func (i *Index) contains(word string) bool {
+	k := sort.SearchStrings(i.words, word)
+	return k < len(i.words) && i.words[k] == word
+}
+
+// MaxDistance is the number of edits a correction may be from query:
+// none below 3 letters, 1 up to 5 letters and 2 beyond
+func MaxDistance(query string) int {
+	switch n := len([]rune(query)); {
+	case n < 3:
+		return 0
+	case n <= 5:
+		return 1
+	default:
+		return 2
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,29 @@
+package suggest
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCorrections(t *testing.T) {
+	index := New([]string{"form", "from", "four", "receive", "relieve", "deceive", "etymology", "language"}, []string{"from"})
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		// One swap is a single edit even where Levenshtein counts two
+		{"fomr", []string{"form", "four"}},
+		{"frmo", []string{"from"}},
+		{"recieve", []string{"receive", "relieve"}},
+		{"etymolgy", []string{"etymology"}},
+		{"langauge", []string{"language"}},
+		{"xyzzyq", nil},
+		{"fo", nil},
+	}
+	for _, tt := range tests {
+		if got := index.Corrections(tt.query, 8); !slices.Equal(got, tt.want) {
+			t.Errorf("Corrections(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
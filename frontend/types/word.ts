@@ -132,21 +132,30 @@ export interface Session {
   words: SessionWord[];
 }
 
+export type GraphNodeType = 'word' | 'root' | 'component' | 'derivative';
+
+export type GraphEdgeRelation = 'origin' | 'component' | 'derivative' | 'explored';
+
 export interface GraphNode {
-  id: string;
-  word: string;
+  id: string;          // "<type>:<label>" (e.g., root:videre)
+  type?: GraphNodeType;
+  word: string;        // 노드 라벨 (단어, 어근, 구성요소, 파생어)
+  meaning?: string;
+  language?: string;   // 어근의 원어 (root 노드)
   etymology: Etymology | null;
   order: number;
+  revision?: number;
 }
 
 export interface GraphEdge {
   source: string;
   target: string;
+  relation?: GraphEdgeRelation;
 }
 
 export interface SessionGraph {
   session: {
-    id: string;
+    id: number;
     name: string | null;
     createdAt: string;
   };
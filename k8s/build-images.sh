@@ -10,7 +10,7 @@ echo "=== Building Docker images for k3s ==="
 
 # Build images
 echo "[1/4] Building api..."
-docker build -t etymograph/api:latest ./api-go
+docker build --build-context rate-limiter=./rate-limiter -t etymograph/api:latest ./api-go
 
 echo "[2/4] Building frontend..."
 docker build -t etymograph/frontend:latest ./frontend
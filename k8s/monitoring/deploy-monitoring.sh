@@ -147,6 +147,7 @@ helm upgrade --install prometheus prometheus-community/kube-prometheus-stack \
 echo "Applying ServiceMonitors..."
 kubectl apply -f api-servicemonitor.yaml
 kubectl apply -f llm-proxy-servicemonitor.yaml
+kubectl apply -f rate-limiter-servicemonitor.yaml
 
 # ===== 커스텀 알림 규칙 적용 =====
 echo "Applying custom alert rules..."
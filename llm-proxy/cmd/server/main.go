@@ -1,13 +1,20 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/epikoding/etymograph/llm-proxy/internal/cache"
 	"github.com/epikoding/etymograph/llm-proxy/internal/config"
 	"github.com/epikoding/etymograph/llm-proxy/internal/handler"
 	"github.com/epikoding/etymograph/llm-proxy/internal/llm"
+	"github.com/epikoding/etymograph/llm-proxy/internal/prompt"
+	"github.com/epikoding/etymograph/llm-proxy/internal/usage"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	"github.com/prometheus/client_golang/prometheus"
@@ -22,7 +29,7 @@ var (
 			Name: "llm_requests_total",
 			Help: "Total number of LLM requests",
 		},
-		[]string{"endpoint", "status"},
+		[]string{"endpoint", "status", "provider"},
 	)
 
 	llmRequestDuration = promauto.NewHistogramVec(
@@ -37,12 +44,88 @@ var (
 	llmTokensUsed = promauto.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "llm_tokens_used_total",
-			Help: "Total LLM tokens used (estimated)",
+			Help: "Total LLM tokens used, as reported by the provider (type: prompt or completion)",
 		},
-		[]string{"type"},
+		[]string{"type", "endpoint", "provider", "model"},
+	)
+
+	llmCostTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "llm_cost_usd_total",
+			Help: "Estimated LLM cost in USD from the configured price table",
+		},
+		[]string{"endpoint", "provider", "model"},
 	)
 )
 
+// usageMiddleware records the token usage of every generation made for the request,
+// including repair attempts and fallbacks that failed after consuming tokens
+func usageMiddleware(tracker *usage.Tracker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		endpoint := c.FullPath()
+		ctx := llm.WithUsageRecorder(c.Request.Context(), func(u llm.Usage) {
+			cost := tracker.Record(endpoint, u)
+			llmTokensUsed.WithLabelValues("prompt", endpoint, u.Provider, u.Model).Add(float64(u.PromptTokens))
+			llmTokensUsed.WithLabelValues("completion", endpoint, u.Provider, u.Model).Add(float64(u.CompletionTokens))
+			llmCostTotal.WithLabelValues(endpoint, u.Provider, u.Model).Add(cost)
+		})
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// budgetMiddleware rejects LLM requests once today's usage reaches a daily budget limit
+func budgetMiddleware(tracker *usage.Tracker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if tracker.Exceeded() {
+			retryAfter := int(usage.ResetIn().Seconds()) + 1
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error":      "daily LLM budget exceeded",
+				"code":       llm.CodeBudgetExceeded,
+				"retryAfter": retryAfter,
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// providerMiddleware records which provider served the request and reports it in
+// the X-LLM-Provider header. The header is set when the provider answers, before
+// the handler writes its response.
+func providerMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := llm.WithProviderRecorder(c.Request.Context(), func(name string) {
+			c.Header("X-LLM-Provider", name)
+		})
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// deadlineMiddleware bounds the request context by the caller's X-Request-Timeout-Ms
+// header, capped at maxTimeout (0 = no cap), so provider calls stop once the caller has given up
+func deadlineMiddleware(maxTimeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timeout := maxTimeout
+		if ms, err := strconv.ParseInt(c.GetHeader("X-Request-Timeout-Ms"), 10, 64); err == nil && ms > 0 {
+			if requested := time.Duration(ms) * time.Millisecond; timeout <= 0 || requested < timeout {
+				timeout = requested
+			}
+		}
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
 // metricsMiddleware collects Prometheus metrics for each request
 func metricsMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -57,58 +140,176 @@ func metricsMiddleware() gin.HandlerFunc {
 			endpoint = "unknown"
 		}
 
-		llmRequestsTotal.WithLabelValues(endpoint, status).Inc()
+		provider := llm.ServedProvider(c.Request.Context())
+		if provider == "" {
+			provider = "none"
+		}
+
+		llmRequestsTotal.WithLabelValues(endpoint, status, provider).Inc()
 		llmRequestDuration.WithLabelValues(endpoint).Observe(duration)
 	}
 }
 
-func main() {
-	// Load .env file if exists
-	_ = godotenv.Load()
-
-	cfg := config.Load()
-
-	// Initialize LLM client based on provider
-	var client llm.LLMClient
-	switch cfg.LLMProvider {
+// newLLMClient builds the client for one provider from the config
+func newLLMClient(cfg *config.Config, provider string) (llm.LLMClient, error) {
+	switch provider {
 	case "gemini":
 		if cfg.GeminiAPIKey == "" {
-			log.Fatal("GEMINI_API_KEY is required when using gemini provider")
+			return nil, fmt.Errorf("GEMINI_API_KEY is required when using gemini provider")
 		}
-		client = llm.NewGeminiClient(cfg.GeminiAPIKey, cfg.GeminiModel)
 		log.Printf("Using Gemini API with model: %s", cfg.GeminiModel)
+		return llm.NewGeminiClient(cfg.GeminiAPIKey, cfg.GeminiModel), nil
 	case "ollama":
-		client = llm.NewOllamaClient(cfg.OllamaURL, cfg.OllamaModel)
 		log.Printf("Using Ollama at %s with model: %s", cfg.OllamaURL, cfg.OllamaModel)
+		return llm.NewOllamaClient(cfg.OllamaURL, cfg.OllamaModel), nil
+	case "openai":
+		log.Printf("Using OpenAI-compatible API at %s with model: %s", cfg.OpenAIBaseURL, cfg.OpenAIModel)
+		return llm.NewOpenAIClient(cfg.OpenAIBaseURL, cfg.OpenAIAPIKey, cfg.OpenAIModel), nil
+	case "anthropic":
+		if cfg.AnthropicAPIKey == "" {
+			return nil, fmt.Errorf("ANTHROPIC_API_KEY is required when using anthropic provider")
+		}
+		log.Printf("Using Anthropic API at %s with model: %s", cfg.AnthropicBaseURL, cfg.AnthropicModel)
+		return llm.NewAnthropicClient(cfg.AnthropicBaseURL, cfg.AnthropicAPIKey, cfg.AnthropicModel, cfg.AnthropicMaxTokens), nil
+	default:
+		return nil, fmt.Errorf("unknown LLM provider: %s (supported: gemini, ollama, openai, anthropic)", provider)
+	}
+}
+
+// newResponseCache builds the coalescing group with the configured cache backend
+func newResponseCache(cfg *config.Config) (*cache.Group, error) {
+	switch cfg.CacheBackend {
+	case "":
+		log.Printf("Response cache disabled (coalescing identical in-flight requests only)")
+		return cache.NewGroup(nil, 0), nil
+	case "memory":
+		log.Printf("Response cache: memory (TTL %s, max %d entries)", cfg.CacheTTL, cfg.CacheMaxEntries)
+		return cache.NewGroup(cache.NewMemoryStore(cfg.CacheMaxEntries), cfg.CacheTTL), nil
+	case "redis":
+		store, err := cache.NewRedisStore(cfg.CacheRedisURL)
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("Response cache: redis (TTL %s)", cfg.CacheTTL)
+		return cache.NewGroup(store, cfg.CacheTTL), nil
+	default:
+		return nil, fmt.Errorf("unknown CACHE_BACKEND: %s (supported: memory, redis)", cfg.CacheBackend)
+	}
+}
+
+func newUsageStore(cfg *config.Config) (usage.Store, error) {
+	switch cfg.UsageBackend {
+	case "", "memory":
+		log.Printf("Usage totals: memory (per replica, reset on restart)")
+		return nil, nil
+	case "redis":
+		store, err := usage.NewRedisStore(cfg.CacheRedisURL)
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("Usage totals: redis")
+		return store, nil
 	default:
-		log.Fatalf("Unknown LLM provider: %s (supported: gemini, ollama)", cfg.LLMProvider)
+		return nil, fmt.Errorf("unknown USAGE_BACKEND: %s (supported: memory, redis)", cfg.UsageBackend)
+	}
+}
+
+func main() {
+	// Load .env file if exists
+	_ = godotenv.Load()
+
+	cfg := config.Load()
+
+	// Initialize LLM clients in routing priority order
+	var providers []llm.Provider
+	for _, name := range cfg.LLMProviders {
+		providerClient, err := newLLMClient(cfg, name)
+		if err != nil {
+			log.Fatal(err)
+		}
+		concurrency, ok := cfg.ProviderConcurrency[name]
+		if !ok {
+			concurrency = cfg.DefaultProviderConcurrency
+		}
+		providers = append(providers, llm.Provider{Name: name, Client: providerClient, Concurrency: concurrency})
+	}
+	client := llm.NewRouterClient(providers, cfg.BreakerFailures, cfg.BreakerCooldown)
+	log.Printf("LLM provider routing order: %s", strings.Join(cfg.LLMProviders, " -> "))
+
+	// Load prompt templates
+	prompts, err := prompt.Load(cfg.PromptsDir)
+	if err != nil {
+		log.Fatalf("Failed to load prompts: %v", err)
+	}
+	for _, p := range prompts.List() {
+		if p.Active {
+			log.Printf("Prompt %s: v%d", p.ID, p.Version)
+		}
+	}
+
+	// Response cache and in-flight request coalescing
+	responses, err := newResponseCache(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Token usage and cost tracking
+	prices, err := usage.ParsePrices(cfg.LLMPrices)
+	if err != nil {
+		log.Fatalf("Failed to parse LLM_PRICES: %v", err)
+	}
+	usageStore, err := newUsageStore(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	tracker := usage.NewTracker(prices, usage.Budget{DailyUSD: cfg.DailyBudgetUSD, DailyTokens: cfg.DailyTokenLimit}, usageStore)
+	if cfg.DailyBudgetUSD > 0 || cfg.DailyTokenLimit > 0 {
+		log.Printf("Daily LLM budget: $%.2f, %d tokens (0 = unlimited)", cfg.DailyBudgetUSD, cfg.DailyTokenLimit)
 	}
 
 	// Initialize handlers
-	etymologyHandler := handler.NewEtymologyHandler(client)
-	derivativesHandler := handler.NewDerivativesHandler(client)
-	synonymsHandler := handler.NewSynonymsHandler(client)
+	etymologyHandler := handler.NewEtymologyHandler(client, prompts, responses, cfg.EtymologyRepairAttempts, handler.BatchConfig{
+		MaxWords:      cfg.BatchMaxWords,
+		PackSize:      cfg.BatchPackSize,
+		RetryAttempts: cfg.BatchRetryAttempts,
+		RetryDelay:    cfg.BatchRetryDelay,
+		WordTimeout:   cfg.RequestTimeout,
+	})
+	derivativesHandler := handler.NewDerivativesHandler(client, prompts, responses)
+	synonymsHandler := handler.NewSynonymsHandler(client, prompts, responses)
+	promptsHandler := handler.NewPromptsHandler(prompts)
+	usageHandler := handler.NewUsageHandler(tracker)
 
 	// Setup router
 	r := gin.Default()
 
-	// Prometheus metrics middleware
+	// Prometheus metrics middleware (outermost, so it sees the served provider)
 	r.Use(metricsMiddleware())
+	r.Use(providerMiddleware())
+	r.Use(usageMiddleware(tracker))
 
 	// Prometheus metrics endpoint
 	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
 
 	// Health check
 	r.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "ok"})
+		c.JSON(200, gin.H{"status": "ok", "providers": client.Status()})
 	})
 
 	// API routes
 	api := r.Group("/api")
 	{
-		api.POST("/etymology", etymologyHandler.Analyze)
-		api.POST("/derivatives", derivativesHandler.Find)
-		api.POST("/synonyms", synonymsHandler.Compare)
+		generate := api.Group("", budgetMiddleware(tracker))
+		single := generate.Group("", deadlineMiddleware(cfg.RequestTimeout))
+		single.POST("/etymology", etymologyHandler.Analyze)
+		single.POST("/etymology/stream", etymologyHandler.AnalyzeStream)
+		single.POST("/derivatives", derivativesHandler.Find)
+		single.POST("/synonyms", synonymsHandler.Compare)
+		// Batches get LLM_REQUEST_TIMEOUT per word from the handler; only the caller's header caps the whole
+		generate.POST("/etymology/batch", deadlineMiddleware(0), etymologyHandler.AnalyzeBatch)
+
+		api.GET("/prompts", promptsHandler.List)
+		api.GET("/usage", usageHandler.Summary)
 	}
 
 	log.Printf("LLM Proxy starting on port %s", cfg.Port)
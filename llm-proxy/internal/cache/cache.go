@@ -0,0 +1,289 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/redis/go-redis/v9"
+)
+
+var cacheRequestsTotal = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "llm_cache_requests_total",
+		Help: "LLM proxy requests by cache result (hit, coalesced, miss)",
+	},
+	[]string{"endpoint", "result"},
+)
+
+// Result values reported in the X-LLM-Cache header and llm_cache_requests_total
+const (
+	ResultHit       = "hit"       // served from the response cache
+	ResultCoalesced = "coalesced" // shared the result of an identical in-flight request
+	ResultMiss      = "miss"      // generated by this request
+)
+
+// Entry is a finished proxy response
+type Entry struct {
+	Status   int    `json:"status"`
+	Body     []byte `json:"body"`
+	Provider string `json:"provider,omitempty"`
+}
+
+// Store keeps entries for a TTL
+type Store interface {
+	// Get returns the entry for key, or nil if there is none
+	Get(ctx context.Context, key string) (*Entry, error)
+	Set(ctx context.Context, key string, entry *Entry, ttl time.Duration) error
+}
+
+// StatusClientClosed is the status of the entry returned to a caller that went
+// away before its response was ready (nginx's "client closed request")
+const StatusClientClosed = 499
+
+// Group coalesces concurrent identical requests and, if it has a store,
+// caches successful responses for ttl
+type Group struct {
+	mu       sync.Mutex
+	inflight map[string]*call
+	store    Store
+	ttl      time.Duration
+}
+
+// call is one in-flight generation shared by every waiting caller
+type call struct {
+	done    chan struct{}
+	entry   *Entry
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// NewGroup creates a group; store may be nil to only coalesce
+func NewGroup(store Store, ttl time.Duration) *Group {
+	return &Group{inflight: make(map[string]*call), store: store, ttl: ttl}
+}
+
+// Do returns the cached entry for key, or runs generate once for all concurrent
+// callers with the same key and shares its response, errors included.
+// Only 200 responses are cached. generate keeps running while any caller is still
+// waiting, so it lasts as long as the caller with the latest deadline, and is cancelled
+// once all of them have gone away. A cancelled generation is forgotten at once, so
+// callers arriving after that start a new one instead of sharing its cancellation.
+// The second return value is one of the Result constants.
+func (g *Group) Do(ctx context.Context, endpoint, key string, generate func(ctx context.Context) *Entry) (*Entry, string) {
+	if entry := g.Get(ctx, key); entry != nil {
+		cacheRequestsTotal.WithLabelValues(endpoint, ResultHit).Inc()
+		return entry, ResultHit
+	}
+
+	result := ResultCoalesced
+	g.mu.Lock()
+	cl, ok := g.inflight[key]
+	if !ok {
+		result = ResultMiss
+		cl = g.start(ctx, key, generate)
+	}
+	cl.waiters++
+	g.mu.Unlock()
+
+	cacheRequestsTotal.WithLabelValues(endpoint, result).Inc()
+
+	select {
+	case <-cl.done:
+		return cl.entry, result
+	case <-ctx.Done():
+		g.mu.Lock()
+		cl.waiters--
+		if cl.waiters == 0 {
+			cl.cancel()
+			if g.inflight[key] == cl {
+				delete(g.inflight, key)
+			}
+		}
+		g.mu.Unlock()
+		return abandonedEntry(ctx.Err()), result
+	}
+}
+
+// abandonedEntry is the response for a caller that stopped waiting, by its context's error
+func abandonedEntry(err error) *Entry {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return &Entry{Status: http.StatusGatewayTimeout, Body: []byte(`{"error":"request timed out","code":"TIMEOUT"}`)}
+	}
+	return &Entry{Status: StatusClientClosed, Body: []byte(`{"error":"request cancelled","code":"CANCELLED"}`)}
+}
+
+// start runs generate for key in the background; callers hold g.mu.
+// The generation is detached from ctx's cancellation and deadline: it ends when
+// the last waiting caller leaves.
+func (g *Group) start(ctx context.Context, key string, generate func(ctx context.Context) *Entry) *call {
+	genCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+
+	cl := &call{done: make(chan struct{}), cancel: cancel}
+	g.inflight[key] = cl
+
+	go func() {
+		defer cancel()
+		entry := generate(genCtx)
+		g.Set(genCtx, key, entry)
+
+		g.mu.Lock()
+		if g.inflight[key] == cl {
+			delete(g.inflight, key)
+		}
+		g.mu.Unlock()
+
+		cl.entry = entry
+		close(cl.done)
+	}()
+	return cl
+}
+
+// Get returns the cached entry for key, or nil. Store errors are logged and treated as misses.
+func (g *Group) Get(ctx context.Context, key string) *Entry {
+	if g.store == nil {
+		return nil
+	}
+	entry, err := g.store.Get(ctx, key)
+	if err != nil {
+		log.Printf("Response cache get failed: %v", err)
+		return nil
+	}
+	return entry
+}
+
+// Set caches entry under key if it is a 200 response
+func (g *Group) Set(ctx context.Context, key string, entry *Entry) {
+	if g.store == nil || entry.Status != 200 {
+		return
+	}
+	if err := g.store.Set(context.WithoutCancel(ctx), key, entry, g.ttl); err != nil {
+		log.Printf("Response cache set failed: %v", err)
+	}
+}
+
+// =============================================================================
+// In-memory store
+// =============================================================================
+
+// MemoryStore is a process-local Store holding at most maxEntries entries
+type MemoryStore struct {
+	mu         sync.Mutex
+	entries    map[string]memoryEntry
+	maxEntries int
+}
+
+type memoryEntry struct {
+	entry     *Entry
+	expiresAt time.Time
+}
+
+func NewMemoryStore(maxEntries int) *MemoryStore {
+	s := &MemoryStore{entries: make(map[string]memoryEntry), maxEntries: maxEntries}
+	go s.sweep()
+	return s
+}
+
+func (s *MemoryStore) Get(ctx context.Context, key string) (*Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[key]
+	if !ok || time.Now().After(e.expiresAt) {
+		return nil, nil
+	}
+	return e.entry, nil
+}
+
+func (s *MemoryStore) Set(ctx context.Context, key string, entry *Entry, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.entries[key]; !exists && s.maxEntries > 0 && len(s.entries) >= s.maxEntries {
+		// Full: drop an arbitrary entry rather than tracking recency
+		for k := range s.entries {
+			delete(s.entries, k)
+			break
+		}
+	}
+	s.entries[key] = memoryEntry{entry: entry, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+// sweep removes expired entries once per minute
+func (s *MemoryStore) sweep() {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		now := time.Now()
+		s.mu.Lock()
+		for k, e := range s.entries {
+			if now.After(e.expiresAt) {
+				delete(s.entries, k)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+// =============================================================================
+// Redis store
+// =============================================================================
+
+// RedisStore shares cached responses between llm-proxy replicas
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+func NewRedisStore(redisURL string) (*RedisStore, error) {
+	opt, err := redis.ParseURL(redisURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse redis URL: %w", err)
+	}
+
+	client := redis.NewClient(opt)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+
+	return &RedisStore{client: client, prefix: "llm-cache:"}, nil
+}
+
+func (s *RedisStore) Get(ctx context.Context, key string) (*Entry, error) {
+	data, err := s.client.Get(ctx, s.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (s *RedisStore) Set(ctx context.Context, key string, entry *Entry, ttl time.Duration) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, s.prefix+key, data, ttl).Err()
+}
+
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
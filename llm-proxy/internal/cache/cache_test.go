@@ -0,0 +1,141 @@
+package cache
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func okEntry() *Entry {
+	return &Entry{Status: http.StatusOK, Body: []byte(`{}`)}
+}
+
+// waitFor fails the test if ch is not closed within a second
+func waitFor(t *testing.T, ch <-chan struct{}, what string) {
+	t.Helper()
+	select {
+	case <-ch:
+	case <-time.After(time.Second):
+		t.Fatalf("timed out waiting for %s", what)
+	}
+}
+
+// waitForWaiters fails the test unless key's in-flight call soon has n waiters
+func waitForWaiters(t *testing.T, g *Group, key string, n int) {
+	t.Helper()
+	for start := time.Now(); time.Since(start) < time.Second; time.Sleep(time.Millisecond) {
+		g.mu.Lock()
+		cl, ok := g.inflight[key]
+		waiters := 0
+		if ok {
+			waiters = cl.waiters
+		}
+		g.mu.Unlock()
+		if waiters == n {
+			return
+		}
+	}
+	t.Fatalf("timed out waiting for %d callers on %s", n, key)
+}
+
+func TestGroupCancelThenJoin(t *testing.T) {
+	g := NewGroup(nil, time.Minute)
+
+	// The first generation notices its cancellation but is slow to return
+	firstStarted, firstCancelled, firstRelease, firstDone := make(chan struct{}), make(chan struct{}), make(chan struct{}), make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		defer close(firstDone)
+		entry, _ := g.Do(ctx, "/test", "key", func(ctx context.Context) *Entry {
+			close(firstStarted)
+			<-ctx.Done()
+			close(firstCancelled)
+			<-firstRelease
+			return &Entry{Status: StatusClientClosed}
+		})
+		if entry.Status != StatusClientClosed {
+			t.Errorf("cancelled caller got status %d, want %d", entry.Status, StatusClientClosed)
+		}
+	}()
+	waitFor(t, firstStarted, "the first generation")
+	cancel()
+	waitFor(t, firstCancelled, "the first generation to be cancelled")
+
+	// A caller arriving before the cancelled generation returns starts a new one
+	secondStarted, secondRelease := make(chan struct{}), make(chan struct{})
+	type outcome struct {
+		entry  *Entry
+		result string
+	}
+	second := make(chan outcome, 1)
+	go func() {
+		entry, result := g.Do(context.Background(), "/test", "key", func(ctx context.Context) *Entry {
+			close(secondStarted)
+			<-secondRelease
+			return okEntry()
+		})
+		second <- outcome{entry, result}
+	}()
+	waitFor(t, secondStarted, "a new generation after the cancelled one")
+
+	// The cancelled generation finishing must not forget the new one
+	close(firstRelease)
+	waitFor(t, firstDone, "the cancelled caller")
+	third := make(chan outcome, 1)
+	go func() {
+		entry, result := g.Do(context.Background(), "/test", "key", func(ctx context.Context) *Entry {
+			t.Error("third caller started its own generation")
+			return okEntry()
+		})
+		third <- outcome{entry, result}
+	}()
+	waitForWaiters(t, g, "key", 2)
+	close(secondRelease)
+
+	if got := <-second; got.entry.Status != http.StatusOK || got.result != ResultMiss {
+		t.Errorf("second caller got %d (%s), want 200 (%s)", got.entry.Status, got.result, ResultMiss)
+	}
+	if got := <-third; got.entry.Status != http.StatusOK || got.result != ResultCoalesced {
+		t.Errorf("third caller got %d (%s), want 200 (%s)", got.entry.Status, got.result, ResultCoalesced)
+	}
+}
+
+func TestGroupJoinerOutlivesFirstDeadline(t *testing.T) {
+	g := NewGroup(nil, time.Minute)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	generate := func(ctx context.Context) *Entry {
+		close(started)
+		select {
+		case <-release:
+			return okEntry()
+		case <-ctx.Done():
+			return &Entry{Status: http.StatusGatewayTimeout}
+		}
+	}
+
+	firstCtx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	first := make(chan *Entry, 1)
+	go func() {
+		entry, _ := g.Do(firstCtx, "/test", "key", generate)
+		first <- entry
+	}()
+	waitFor(t, started, "the generation")
+
+	second := make(chan *Entry, 1)
+	go func() {
+		entry, _ := g.Do(context.Background(), "/test", "key", generate)
+		second <- entry
+	}()
+	waitForWaiters(t, g, "key", 2)
+
+	if entry := <-first; entry.Status != http.StatusGatewayTimeout {
+		t.Errorf("first caller got status %d after its deadline, want 504", entry.Status)
+	}
+	close(release)
+	if entry := <-second; entry.Status != http.StatusOK {
+		t.Errorf("joiner got status %d, want the generation's 200", entry.Status)
+	}
+}
@@ -2,25 +2,131 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
 	Port         string
-	LLMProvider  string // "ollama" or "gemini"
+	LLMProvider  string // "ollama", "gemini", "openai" or "anthropic"
 	OllamaURL    string
 	OllamaModel  string
 	GeminiAPIKey string
 	GeminiModel  string
+
+	// OpenAI chat completions format (also vLLM, llama.cpp server, LM Studio)
+	OpenAIBaseURL string
+	OpenAIAPIKey  string
+	OpenAIModel   string
+
+	// Anthropic Messages format
+	AnthropicBaseURL   string
+	AnthropicAPIKey    string
+	AnthropicModel     string
+	AnthropicMaxTokens int
+
+	// LLMProviders is the routing priority list; the first provider serves
+	// requests and the rest are fallbacks. Defaults to [LLMProvider].
+	LLMProviders []string
+
+	// Per-provider circuit breaker: after BreakerFailures consecutive retryable
+	// errors a provider is skipped for BreakerCooldown
+	BreakerFailures int
+	BreakerCooldown time.Duration
+
+	// ProviderConcurrency caps concurrent batch generations per provider
+	// ("gemini=8,ollama=1"); providers not listed get DefaultProviderConcurrency
+	ProviderConcurrency        map[string]int
+	DefaultProviderConcurrency int
+
+	// RequestTimeout bounds each request's provider calls. Callers can ask for less
+	// with the X-Request-Timeout-Ms header, but never more.
+	RequestTimeout time.Duration
+
+	// Response cache: "memory", "redis" or "" (identical in-flight requests are
+	// always coalesced; this only controls whether finished responses are kept)
+	CacheBackend    string
+	CacheTTL        time.Duration
+	CacheMaxEntries int    // memory backend only
+	CacheRedisURL   string // redis backend only (also used by the redis usage backend)
+
+	// LLMPrices overrides the built-in price table: "model=input/output,..."
+	// in USD per 1M tokens. Used to estimate cost for GET /api/usage.
+	LLMPrices string
+
+	// Daily limits on estimated cost and total tokens (UTC day); 0 disables.
+	// LLM requests are rejected with 429 once either is reached.
+	DailyBudgetUSD  float64
+	DailyTokenLimit int64
+
+	// Usage totals: "memory" (per replica, reset on restart) or "redis"
+	// (shared between replicas and kept across restarts)
+	UsageBackend string
+
+	// PromptsDir optionally adds prompt templates ("<id>.v<version>.tmpl") on top
+	// of the embedded ones; the highest version of each prompt is used
+	PromptsDir string
+
+	// EtymologyRepairAttempts is how many times an etymology response that
+	// violates the prompt rules is sent back to the model for repair
+	EtymologyRepairAttempts int
+
+	// POST /api/etymology/batch: words per request, short words packed per prompt,
+	// and retries (with exponential backoff) on provider quota errors
+	BatchMaxWords      int
+	BatchPackSize      int
+	BatchRetryAttempts int
+	BatchRetryDelay    time.Duration
 }
 
 func Load() *Config {
+	provider := getEnv("LLM_PROVIDER", "gemini")
+
 	return &Config{
 		Port:         getEnv("PORT", "8081"),
-		LLMProvider:  getEnv("LLM_PROVIDER", "gemini"),
+		LLMProvider:  provider,
 		OllamaURL:    getEnv("OLLAMA_URL", "http://localhost:11434"),
 		OllamaModel:  getEnv("OLLAMA_MODEL", "qwen3:8b"),
 		GeminiAPIKey: getEnv("GEMINI_API_KEY", ""),
 		GeminiModel:  getEnv("GEMINI_MODEL", "gemini-2.0-flash"),
+
+		OpenAIBaseURL: getEnv("OPENAI_BASE_URL", "https://api.openai.com/v1"),
+		OpenAIAPIKey:  getEnv("OPENAI_API_KEY", ""),
+		OpenAIModel:   getEnv("OPENAI_MODEL", "gpt-4o-mini"),
+
+		AnthropicBaseURL:   getEnv("ANTHROPIC_BASE_URL", "https://api.anthropic.com"),
+		AnthropicAPIKey:    getEnv("ANTHROPIC_API_KEY", ""),
+		AnthropicModel:     getEnv("ANTHROPIC_MODEL", "claude-3-5-haiku-latest"),
+		AnthropicMaxTokens: getEnvInt("ANTHROPIC_MAX_TOKENS", 4096),
+
+		LLMProviders:    getEnvList("LLM_PROVIDERS", []string{provider}),
+		BreakerFailures: getEnvInt("LLM_BREAKER_FAILURES", 3),
+		BreakerCooldown: getEnvDuration("LLM_BREAKER_COOLDOWN", 30*time.Second),
+
+		ProviderConcurrency:        getEnvIntMap("LLM_PROVIDER_CONCURRENCY", map[string]int{"ollama": 1}),
+		DefaultProviderConcurrency: getEnvInt("LLM_PROVIDER_CONCURRENCY_DEFAULT", 4),
+
+		RequestTimeout: getEnvDuration("LLM_REQUEST_TIMEOUT", 120*time.Second),
+
+		CacheBackend:    getEnv("CACHE_BACKEND", ""),
+		CacheTTL:        getEnvDuration("CACHE_TTL", 24*time.Hour),
+		CacheMaxEntries: getEnvInt("CACHE_MAX_ENTRIES", 10000),
+		CacheRedisURL:   getEnv("REDIS_URL", "redis://localhost:6379"),
+
+		LLMPrices:       getEnv("LLM_PRICES", ""),
+		DailyBudgetUSD:  getEnvFloat("USAGE_DAILY_BUDGET_USD", 0),
+		DailyTokenLimit: int64(getEnvInt("USAGE_DAILY_TOKEN_LIMIT", 0)),
+		UsageBackend:    getEnv("USAGE_BACKEND", "memory"),
+
+		PromptsDir: getEnv("PROMPTS_DIR", ""),
+
+		EtymologyRepairAttempts: getEnvInt("ETYMOLOGY_REPAIR_ATTEMPTS", 2),
+
+		BatchMaxWords:      getEnvInt("ETYMOLOGY_BATCH_MAX_WORDS", 50),
+		BatchPackSize:      getEnvInt("ETYMOLOGY_BATCH_PACK_SIZE", 5),
+		BatchRetryAttempts: getEnvInt("ETYMOLOGY_BATCH_RETRY_ATTEMPTS", 3),
+		BatchRetryDelay:    getEnvDuration("ETYMOLOGY_BATCH_RETRY_DELAY", 2*time.Second),
 	}
 }
 
@@ -30,3 +136,59 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed >= 0 {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil && parsed >= 0 {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+// getEnvList parses a comma-separated list, dropping empty entries
+func getEnvList(key string, defaultValue []string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	if len(list) == 0 {
+		return defaultValue
+	}
+	return list
+}
+
+// getEnvIntMap parses "key=value,..." pairs on top of defaultValue
+func getEnvIntMap(key string, defaultValue map[string]int) map[string]int {
+	result := make(map[string]int, len(defaultValue))
+	for k, v := range defaultValue {
+		result[k] = v
+	}
+	for _, item := range getEnvList(key, nil) {
+		name, value, ok := strings.Cut(item, "=")
+		if parsed, err := strconv.Atoi(strings.TrimSpace(value)); ok && err == nil && parsed > 0 {
+			result[strings.TrimSpace(name)] = parsed
+		}
+	}
+	return result
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil && parsed >= 0 {
+			return parsed
+		}
+	}
+	return defaultValue
+}
@@ -0,0 +1,331 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/epikoding/etymograph/llm-proxy/internal/cache"
+	"github.com/epikoding/etymograph/llm-proxy/internal/llm"
+	"github.com/epikoding/etymograph/llm-proxy/internal/prompt"
+	"github.com/gin-gonic/gin"
+)
+
+// maxPackedWordLength is the longest word packed together with others into one prompt;
+// longer words tend to have long etymologies that crowd out the rest of the response
+const maxPackedWordLength = 10
+
+// BatchConfig controls POST /api/etymology/batch
+type BatchConfig struct {
+	MaxWords      int           // words accepted per request
+	PackSize      int           // short words per packed prompt; 1 disables packing
+	RetryAttempts int           // attempts per generation on provider quota errors
+	RetryDelay    time.Duration // first retry delay, doubled after each attempt
+	// WordTimeout bounds each generation (one word or pack, retries included) once it
+	// has a provider slot; a batch may take WordTimeout per word. 0 means no limit.
+	WordTimeout time.Duration
+}
+
+type BatchRequest struct {
+	Words    []string `json:"words" binding:"required"`
+	Language string   `json:"language"`
+	// Pack asks for several short words to share one prompt when the provider has a JSON mode
+	Pack bool `json:"pack"`
+}
+
+// BatchResult is the outcome for one word. Status and Body are what POST /api/etymology
+// would have responded with for that word alone.
+type BatchResult struct {
+	Word          string          `json:"word"`
+	Status        int             `json:"status"`
+	Body          json.RawMessage `json:"body"`
+	PromptID      string          `json:"promptId,omitempty"`
+	PromptVersion int             `json:"promptVersion,omitempty"`
+	Provider      string          `json:"provider,omitempty"`
+	Cache         string          `json:"cache,omitempty"`
+	Packed        bool            `json:"packed,omitempty"`
+}
+
+// batchItem is one word of a batch while it is being scheduled
+type batchItem struct {
+	req        EtymologyRequest
+	word       string // without the affix dash
+	promptText string
+	tmpl       *prompt.Template
+	kind       llm.EtymologyKind
+	key        string
+	result     BatchResult
+}
+
+// batchScheduler limits how many batch generations run against each provider at once,
+// across all batch requests, and retries quota errors
+type batchScheduler struct {
+	client llm.LLMClient // retrying wrapper around the handler's client
+	base   llm.LLMClient
+	cfg    BatchConfig
+
+	mu    sync.Mutex
+	slots map[string]chan struct{}
+}
+
+func newBatchScheduler(client llm.LLMClient, cfg BatchConfig) *batchScheduler {
+	return &batchScheduler{
+		client: llm.NewRetryClient(client, cfg.RetryAttempts, cfg.RetryDelay),
+		base:   client,
+		cfg:    cfg,
+		slots:  make(map[string]chan struct{}),
+	}
+}
+
+// acquire waits for a free slot on the current primary provider
+func (s *batchScheduler) acquire(ctx context.Context) (func(), error) {
+	primary := llm.PrimaryProvider(s.base)
+	size := primary.Concurrency
+	if size < 1 {
+		size = 1
+	}
+
+	s.mu.Lock()
+	slots, ok := s.slots[primary.Name]
+	if !ok {
+		slots = make(chan struct{}, size)
+		s.slots[primary.Name] = slots
+	}
+	s.mu.Unlock()
+
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// each runs fn(ctx, i) for i in [0, n), each holding a provider slot and bounded by
+// WordTimeout from when it got the slot. Jobs still waiting for a slot when ctx ends are skipped.
+func (s *batchScheduler) each(ctx context.Context, n int, fn func(ctx context.Context, i int)) {
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			release, err := s.acquire(ctx)
+			if err != nil {
+				return
+			}
+			defer release()
+
+			jobCtx := ctx
+			if s.cfg.WordTimeout > 0 {
+				var cancel context.CancelFunc
+				jobCtx, cancel = context.WithTimeout(ctx, s.cfg.WordTimeout)
+				defer cancel()
+			}
+			fn(jobCtx, i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// AnalyzeBatch analyzes several words in one request, for bulk jobs.
+// Words are scheduled with the primary provider's concurrency limit and retried on
+// quota errors; with "pack", short words are sent several to a prompt where the
+// provider supports it. Each word gets its own result; the request itself succeeds
+// even if some words fail. The request is not bound by LLM_REQUEST_TIMEOUT as a whole
+// but by WordTimeout per word, or less if the caller's X-Request-Timeout-Ms says so.
+func (h *EtymologyHandler) AnalyzeBatch(c *gin.Context) {
+	var req BatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil || len(req.Words) == 0 {
+		c.JSON(invalidRequestBody("words is required"))
+		return
+	}
+	if len(req.Words) > h.batch.cfg.MaxWords {
+		c.JSON(invalidRequestBody(fmt.Sprintf("at most %d words per batch", h.batch.cfg.MaxWords)))
+		return
+	}
+
+	ctx := c.Request.Context()
+	if h.batch.cfg.WordTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.batch.cfg.WordTimeout*time.Duration(len(req.Words)))
+		defer cancel()
+	}
+	targetLang := req.Language
+	if targetLang == "" {
+		targetLang = "Korean"
+	}
+
+	items := make([]*batchItem, len(req.Words))
+	var pending []*batchItem
+	for i, word := range req.Words {
+		item := &batchItem{req: EtymologyRequest{Word: word, Language: targetLang}}
+		item.result.Word = word
+		items[i] = item
+
+		if strings.TrimSpace(word) == "" {
+			item.setResult(jsonEntry(invalidRequestBody("word is required")), "")
+			continue
+		}
+		_, item.word = detectWordType(word)
+
+		var err error
+		item.promptText, item.tmpl, item.kind, _, err = h.etymologyPrompt(item.req)
+		if err != nil {
+			item.setResult(jsonEntry(internalErrorBody(err)), "")
+			continue
+		}
+		item.result.PromptID, item.result.PromptVersion = item.tmpl.ID, item.tmpl.Version
+		item.key = etymologyCacheKey(item.req, item.tmpl, targetLang)
+
+		if entry := h.cache.Get(ctx, item.key); entry != nil {
+			item.setResult(entry, cache.ResultHit)
+			continue
+		}
+		pending = append(pending, item)
+	}
+
+	if req.Pack && h.batch.cfg.PackSize > 1 && llm.SupportsJSONMode(h.client) {
+		packs := packItems(pending, h.batch.cfg.PackSize)
+		h.batch.each(ctx, len(packs), func(ctx context.Context, i int) {
+			h.analyzePacked(ctx, packs[i], targetLang)
+		})
+	}
+
+	var remaining []*batchItem
+	for _, item := range pending {
+		if item.result.Status == 0 {
+			remaining = append(remaining, item)
+		}
+	}
+	h.batch.each(ctx, len(remaining), func(ctx context.Context, i int) {
+		item := remaining[i]
+		wordCtx := llm.WithProviderRecorder(ctx, nil)
+		entry, result := h.cache.Do(wordCtx, c.FullPath(), item.key, generated(func(ctx context.Context) *cache.Entry {
+			return h.analyze(ctx, h.batch.client, item.promptText, item.kind, targetLang)
+		}))
+		item.setResult(entry, result)
+	})
+
+	results := make([]BatchResult, len(items))
+	succeeded := 0
+	providers := map[string]bool{}
+	for i, item := range items {
+		if item.result.Status == 0 {
+			item.setResult(jsonEntry(http.StatusGatewayTimeout, gin.H{
+				"error": "batch request ended before this word was analyzed",
+				"code":  llm.CodeTimeout,
+			}), "")
+		}
+		if item.result.Status == http.StatusOK {
+			succeeded++
+		}
+		if item.result.Provider != "" {
+			providers[item.result.Provider] = true
+		}
+		results[i] = item.result
+	}
+
+	// Report the provider for metrics when every word that reached one agrees
+	if len(providers) == 1 {
+		for name := range providers {
+			llm.RecordProvider(ctx, name)
+		}
+	} else if len(providers) > 1 {
+		llm.RecordProvider(ctx, "mixed")
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"results":   results,
+		"succeeded": succeeded,
+		"failed":    len(results) - succeeded,
+	})
+}
+
+// setResult fills the word's result from a response entry
+func (item *batchItem) setResult(entry *cache.Entry, cacheResult string) {
+	item.result.Status = entry.Status
+	item.result.Body = entry.Body
+	item.result.Provider = entry.Provider
+	item.result.Cache = cacheResult
+}
+
+// packItems groups short plain words into packs of up to size; other words are left out
+func packItems(items []*batchItem, size int) [][]*batchItem {
+	var packs [][]*batchItem
+	var pack []*batchItem
+	for _, item := range items {
+		if item.kind != llm.EtymologyKindWord || len(item.word) > maxPackedWordLength {
+			continue
+		}
+		pack = append(pack, item)
+		if len(pack) == size {
+			packs = append(packs, pack)
+			pack = nil
+		}
+	}
+	if len(pack) > 1 {
+		packs = append(packs, pack)
+	}
+	return packs
+}
+
+// analyzePacked asks for several words' etymologies in one prompt. Words missing from
+// the response or violating the prompt rules are left for individual analysis.
+func (h *EtymologyHandler) analyzePacked(ctx context.Context, items []*batchItem, targetLang string) {
+	tmpl, err := h.prompts.Get(prompt.EtymologyBatch)
+	if err != nil {
+		log.Printf("Etymology batch packing unavailable: %v", err)
+		return
+	}
+	words := make([]string, len(items))
+	for i, item := range items {
+		words[i] = item.word
+	}
+	promptText, err := tmpl.Render(prompt.BatchData{Words: words, Language: targetLang, Prompt: items[0].promptText})
+	if err != nil {
+		log.Printf("Etymology batch packing unavailable: %v", err)
+		return
+	}
+
+	ctx = llm.WithProviderRecorder(ctx, nil)
+	jsonStr, _, err := llm.GenerateJSON(ctx, h.batch.client, promptText, llm.BatchEtymologySchema())
+	if err != nil {
+		log.Printf("Packed etymology for %d words failed, analyzing them individually: %v", len(items), err)
+		return
+	}
+
+	var resp struct {
+		Results []json.RawMessage `json:"results"`
+	}
+	if err := json.Unmarshal([]byte(jsonStr), &resp); err != nil {
+		log.Printf("Packed etymology response is not a result list, analyzing words individually: %v", err)
+		return
+	}
+	byWord := make(map[string]json.RawMessage, len(resp.Results))
+	for _, raw := range resp.Results {
+		var result struct {
+			Word string `json:"word"`
+		}
+		if json.Unmarshal(raw, &result) == nil {
+			byWord[strings.ToLower(strings.TrimSpace(result.Word))] = raw
+		}
+	}
+
+	provider := llm.ServedProvider(ctx)
+	for _, item := range items {
+		raw, ok := byWord[strings.ToLower(item.word)]
+		if !ok || len(llm.ValidateEtymology(string(raw), item.kind, targetLang)) > 0 {
+			continue
+		}
+		entry := &cache.Entry{Status: http.StatusOK, Body: raw, Provider: provider}
+		h.cache.Set(ctx, item.key, entry)
+		item.setResult(entry, cache.ResultMiss)
+		item.result.Packed = true
+		item.result.PromptID, item.result.PromptVersion = tmpl.ID, tmpl.Version
+	}
+}
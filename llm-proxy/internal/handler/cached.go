@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/epikoding/etymograph/llm-proxy/internal/cache"
+	"github.com/epikoding/etymograph/llm-proxy/internal/llm"
+	"github.com/epikoding/etymograph/llm-proxy/internal/prompt"
+	"github.com/gin-gonic/gin"
+)
+
+// serveCached answers from the response cache or an identical in-flight request when
+// possible, otherwise runs generate. The X-LLM-Cache header reports which one happened.
+func serveCached(c *gin.Context, group *cache.Group, key string, generate func(ctx context.Context) *cache.Entry) {
+	ctx := c.Request.Context()
+	entry, result := group.Do(ctx, c.FullPath(), key, generated(generate))
+
+	c.Header("X-LLM-Cache", result)
+	if entry.Provider != "" {
+		llm.RecordProvider(ctx, entry.Provider)
+	}
+	c.Data(entry.Status, "application/json", entry.Body)
+}
+
+// generated wraps generate to tag its entry with the provider that served it,
+// so requests answered from the cache or a coalesced call can report it too.
+// The generation gets its own recorder: it can outlive the request that started
+// it, whose recorder writes to a gin.Context that gin may already have reused.
+func generated(generate func(ctx context.Context) *cache.Entry) func(ctx context.Context) *cache.Entry {
+	return func(ctx context.Context) *cache.Entry {
+		ctx = llm.WithProviderRecorder(ctx, nil)
+		entry := generate(ctx)
+		entry.Provider = llm.ServedProvider(ctx)
+		return entry
+	}
+}
+
+// jsonEntry builds a cacheable response from a JSON-serializable body
+func jsonEntry(status int, body interface{}) *cache.Entry {
+	data, err := json.Marshal(body)
+	if err != nil {
+		data = []byte(`{"error":"failed to encode response","code":"INTERNAL_ERROR"}`)
+	}
+	return &cache.Entry{Status: status, Body: data}
+}
+
+// generateEntry runs a JSON prompt that needs no validation and returns the response to send
+func generateEntry(ctx context.Context, client llm.LLMClient, promptText string) *cache.Entry {
+	jsonStr, response, err := llm.GenerateJSON(ctx, client, promptText, nil)
+	if err != nil {
+		if response == "" {
+			return jsonEntry(errorBody(err))
+		}
+		return jsonEntry(badOutputBody(response))
+	}
+	return &cache.Entry{Status: http.StatusOK, Body: []byte(jsonStr)}
+}
+
+// wordCacheKey identifies identical single-word requests: same endpoint, word and prompt version
+func wordCacheKey(endpoint, word string, tmpl *prompt.Template) string {
+	return fmt.Sprintf("%s:%s:%s.v%d", endpoint, strings.TrimSpace(word), tmpl.ID, tmpl.Version)
+}
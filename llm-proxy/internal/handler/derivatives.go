@@ -1,19 +1,22 @@
 package handler
 
 import (
-	"fmt"
-	"net/http"
+	"context"
 
+	"github.com/epikoding/etymograph/llm-proxy/internal/cache"
 	"github.com/epikoding/etymograph/llm-proxy/internal/llm"
+	"github.com/epikoding/etymograph/llm-proxy/internal/prompt"
 	"github.com/gin-gonic/gin"
 )
 
 type DerivativesHandler struct {
-	client llm.LLMClient
+	client  llm.LLMClient
+	prompts *prompt.Registry
+	cache   *cache.Group
 }
 
-func NewDerivativesHandler(client llm.LLMClient) *DerivativesHandler {
-	return &DerivativesHandler{client: client}
+func NewDerivativesHandler(client llm.LLMClient, prompts *prompt.Registry, cache *cache.Group) *DerivativesHandler {
+	return &DerivativesHandler{client: client, prompts: prompts, cache: cache}
 }
 
 type DerivativesRequest struct {
@@ -23,25 +26,23 @@ type DerivativesRequest struct {
 func (h *DerivativesHandler) Find(c *gin.Context) {
 	var req DerivativesRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "word is required"})
+		c.JSON(invalidRequestBody("word is required"))
 		return
 	}
 
-	prompt := fmt.Sprintf(llm.DerivativesPrompt, req.Word)
-	response, err := h.client.Generate(c.Request.Context(), prompt)
+	tmpl, err := h.prompts.Get(prompt.Derivatives)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(internalErrorBody(err))
 		return
 	}
-
-	jsonStr, err := llm.ExtractJSON(response)
+	promptText, err := tmpl.Render(prompt.WordData{Word: req.Word})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":       "failed to parse LLM response",
-			"rawResponse": response,
-		})
+		c.JSON(internalErrorBody(err))
 		return
 	}
+	setPromptHeaders(c, tmpl)
 
-	c.Data(http.StatusOK, "application/json", []byte(jsonStr))
+	serveCached(c, h.cache, wordCacheKey("derivatives", req.Word, tmpl), func(ctx context.Context) *cache.Entry {
+		return generateEntry(ctx, h.client, promptText)
+	})
 }
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"math"
+	"net/http"
+
+	"github.com/epikoding/etymograph/llm-proxy/internal/llm"
+	"github.com/gin-gonic/gin"
+)
+
+// Every error response is {"error": message, "code": one of the llm.Code constants}, plus
+// "provider" and "retryAfter" (seconds) when known. Streams send the same body in an
+// "error" event with the HTTP status it would have had under "status".
+
+// errorBody classifies a generation error into its status and error response
+func errorBody(err error) (int, gin.H) {
+	f := llm.Classify(err)
+	body := gin.H{"error": err.Error(), "code": f.Code}
+	if f.Provider != "" {
+		body["provider"] = f.Provider
+	}
+	if f.RetryAfter > 0 {
+		body["retryAfter"] = int(math.Ceil(f.RetryAfter.Seconds()))
+	}
+	return f.Status, body
+}
+
+// badOutputBody is the error response for model output that is not the requested JSON
+func badOutputBody(response string) (int, gin.H) {
+	return http.StatusBadGateway, gin.H{
+		"error":       "failed to parse LLM response",
+		"code":        llm.CodeBadOutput,
+		"rawResponse": response,
+	}
+}
+
+// internalErrorBody is the error response for failures inside llm-proxy, such as prompt rendering
+func internalErrorBody(err error) (int, gin.H) {
+	return http.StatusInternalServerError, gin.H{"error": err.Error(), "code": llm.CodeInternal}
+}
+
+// invalidRequestBody is the error response for a malformed request
+func invalidRequestBody(message string) (int, gin.H) {
+	return http.StatusBadRequest, gin.H{"error": message, "code": llm.CodeInvalidRequest}
+}
+
+// streamError sends an error response as a server-sent "error" event
+func streamError(c *gin.Context, status int, body gin.H) {
+	body["status"] = status
+	streamEvent(c, "error", body)
+}
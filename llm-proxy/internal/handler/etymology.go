@@ -1,20 +1,36 @@
 package handler
 
 import (
-	"fmt"
+	"context"
+	"encoding/json"
+	"log"
 	"net/http"
 	"strings"
 
+	"github.com/epikoding/etymograph/llm-proxy/internal/cache"
 	"github.com/epikoding/etymograph/llm-proxy/internal/llm"
+	"github.com/epikoding/etymograph/llm-proxy/internal/prompt"
 	"github.com/gin-gonic/gin"
 )
 
 type EtymologyHandler struct {
-	client llm.LLMClient
+	client         llm.LLMClient
+	prompts        *prompt.Registry
+	cache          *cache.Group
+	repairAttempts int
+	batch          *batchScheduler
 }
 
-func NewEtymologyHandler(client llm.LLMClient) *EtymologyHandler {
-	return &EtymologyHandler{client: client}
+// NewEtymologyHandler creates a handler that re-prompts the model up to
+// repairAttempts times when a response violates the prompt rules
+func NewEtymologyHandler(client llm.LLMClient, prompts *prompt.Registry, cache *cache.Group, repairAttempts int, batch BatchConfig) *EtymologyHandler {
+	return &EtymologyHandler{
+		client:         client,
+		prompts:        prompts,
+		cache:          cache,
+		repairAttempts: repairAttempts,
+		batch:          newBatchScheduler(client, batch),
+	}
 }
 
 type EtymologyRequest struct {
@@ -48,13 +64,21 @@ func detectWordType(word string) (WordType, string) {
 	return WordTypeNormal, word
 }
 
-func (h *EtymologyHandler) Analyze(c *gin.Context) {
-	var req EtymologyRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "word is required"})
-		return
+// etymologyKind maps a word type to the response shape it is validated against
+func etymologyKind(wordType WordType) llm.EtymologyKind {
+	switch wordType {
+	case WordTypeSuffix:
+		return llm.EtymologyKindSuffix
+	case WordTypePrefix:
+		return llm.EtymologyKindPrefix
+	default:
+		return llm.EtymologyKindWord
 	}
+}
 
+// etymologyPrompt renders the prompt for req and returns it with its template,
+// the response kind and the target language
+func (h *EtymologyHandler) etymologyPrompt(req EtymologyRequest) (string, *prompt.Template, llm.EtymologyKind, string, error) {
 	// Default to Korean if no language specified
 	targetLang := req.Language
 	if targetLang == "" {
@@ -64,32 +88,198 @@ func (h *EtymologyHandler) Analyze(c *gin.Context) {
 	// Detect word type based on dash position
 	wordType, cleanWord := detectWordType(req.Word)
 
-	var prompt string
+	id := prompt.Etymology
 	switch wordType {
 	case WordTypeSuffix:
-		// SuffixEtymologyPrompt has 3 %s placeholders: word, language, word (for JSON)
-		prompt = fmt.Sprintf(llm.SuffixEtymologyPrompt, cleanWord, targetLang, cleanWord)
+		id = prompt.EtymologySuffix
 	case WordTypePrefix:
-		// PrefixEtymologyPrompt has 3 %s placeholders: word, language, word (for JSON)
-		prompt = fmt.Sprintf(llm.PrefixEtymologyPrompt, cleanWord, targetLang, cleanWord)
-	default:
-		prompt = fmt.Sprintf(llm.EtymologyPrompt, cleanWord, targetLang)
+		id = prompt.EtymologyPrefix
 	}
 
-	response, err := h.client.Generate(c.Request.Context(), prompt)
+	tmpl, err := h.prompts.Get(id)
+	if err != nil {
+		return "", nil, "", "", err
+	}
+	text, err := tmpl.Render(prompt.WordData{Word: cleanWord, Language: targetLang})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return "", nil, "", "", err
+	}
+	return text, tmpl, etymologyKind(wordType), targetLang, nil
+}
+
+func (h *EtymologyHandler) Analyze(c *gin.Context) {
+	var req EtymologyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(invalidRequestBody("word is required"))
 		return
 	}
 
-	jsonStr, err := llm.ExtractJSON(response)
+	promptText, tmpl, kind, targetLang, err := h.etymologyPrompt(req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":       "failed to parse LLM response",
-			"rawResponse": response,
-		})
+		c.JSON(internalErrorBody(err))
+		return
+	}
+	setPromptHeaders(c, tmpl)
+
+	key := etymologyCacheKey(req, tmpl, targetLang)
+	serveCached(c, h.cache, key, func(ctx context.Context) *cache.Entry {
+		return h.analyze(ctx, h.client, promptText, kind, targetLang)
+	})
+}
+
+// analyze generates and validates an etymology, returning the response to send
+func (h *EtymologyHandler) analyze(ctx context.Context, client llm.LLMClient, promptText string, kind llm.EtymologyKind, targetLang string) *cache.Entry {
+	jsonStr, response, err := llm.GenerateJSON(ctx, client, promptText, llm.EtymologySchema(kind))
+	if err != nil {
+		if response == "" {
+			return jsonEntry(errorBody(err))
+		}
+		return jsonEntry(badOutputBody(response))
+	}
+
+	jsonStr, violations, attempts, err := h.repair(ctx, client, promptText, jsonStr, kind, targetLang)
+	if err != nil {
+		return jsonEntry(errorBody(err))
+	}
+	if len(violations) > 0 {
+		return jsonEntry(schemaViolationBody(violations, attempts))
+	}
+
+	return &cache.Entry{Status: http.StatusOK, Body: []byte(jsonStr)}
+}
+
+// etymologyCacheKey identifies identical etymology requests: same word, language and prompt version
+func etymologyCacheKey(req EtymologyRequest, tmpl *prompt.Template, targetLang string) string {
+	return wordCacheKey("etymology", req.Word+":"+targetLang, tmpl)
+}
+
+// AnalyzeStream is the server-sent events variant of Analyze.
+// It emits "chunk" events ({"text"}) with model output as it is generated, then either
+// a "result" event with the validated etymology JSON or an "error" event carrying the
+// status and body Analyze would have responded with.
+func (h *EtymologyHandler) AnalyzeStream(c *gin.Context) {
+	var req EtymologyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(invalidRequestBody("word is required"))
+		return
+	}
+
+	promptText, tmpl, kind, targetLang, err := h.etymologyPrompt(req)
+	if err != nil {
+		c.JSON(internalErrorBody(err))
+		return
+	}
+	setPromptHeaders(c, tmpl)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	ctx := c.Request.Context()
+	key := etymologyCacheKey(req, tmpl, targetLang)
+	if entry := h.cache.Get(ctx, key); entry != nil {
+		c.Header("X-LLM-Cache", cache.ResultHit)
+		if entry.Provider != "" {
+			llm.RecordProvider(ctx, entry.Provider)
+		}
+		streamEvent(c, "result", json.RawMessage(entry.Body))
+		return
+	}
+	c.Header("X-LLM-Cache", cache.ResultMiss)
+
+	jsonStr, response, err := llm.GenerateJSONStream(ctx, h.client, promptText, llm.EtymologySchema(kind), func(text string) {
+		streamEvent(c, "chunk", gin.H{"text": text})
+	})
+	if err != nil {
+		if response == "" {
+			status, body := errorBody(err)
+			streamError(c, status, body)
+			return
+		}
+		status, body := badOutputBody(response)
+		streamError(c, status, body)
+		return
+	}
+
+	jsonStr, violations, attempts, err := h.repair(ctx, h.client, promptText, jsonStr, kind, targetLang)
+	if err != nil {
+		status, body := errorBody(err)
+		streamError(c, status, body)
+		return
+	}
+	if len(violations) > 0 {
+		status, body := schemaViolationBody(violations, attempts)
+		streamError(c, status, body)
 		return
 	}
 
-	c.Data(http.StatusOK, "application/json", []byte(jsonStr))
+	h.cache.Set(ctx, key, &cache.Entry{Status: http.StatusOK, Body: []byte(jsonStr), Provider: llm.ServedProvider(ctx)})
+	streamEvent(c, "result", json.RawMessage(jsonStr))
+}
+
+// schemaViolationBody is the error response for an etymology that still breaks the
+// prompt rules after the repair attempts
+func schemaViolationBody(violations []llm.Violation, attempts int) (int, gin.H) {
+	return http.StatusUnprocessableEntity, gin.H{
+		"error":          "LLM response violates etymology schema",
+		"code":           llm.CodeSchemaViolation,
+		"violations":     violations,
+		"repairAttempts": attempts,
+	}
+}
+
+// streamEvent writes one server-sent event and flushes it to the client
+func streamEvent(c *gin.Context, event string, data interface{}) {
+	c.SSEvent(event, data)
+	c.Writer.Flush()
+}
+
+// repair validates jsonStr and, while it has violations, asks the model to fix them.
+// It returns the last JSON, its remaining violations and the number of repair attempts made.
+func (h *EtymologyHandler) repair(ctx context.Context, client llm.LLMClient, promptText, jsonStr string, kind llm.EtymologyKind, targetLang string) (string, []llm.Violation, int, error) {
+	violations := llm.ValidateEtymology(jsonStr, kind, targetLang)
+	if len(violations) == 0 {
+		return jsonStr, violations, 0, nil
+	}
+
+	repairTmpl, err := h.prompts.Get(prompt.EtymologyRepair)
+	if err != nil {
+		return jsonStr, violations, 0, err
+	}
+
+	attempts := 0
+	for len(violations) > 0 && attempts < h.repairAttempts {
+		attempts++
+		log.Printf("Etymology response has %d violations, repair attempt %d/%d", len(violations), attempts, h.repairAttempts)
+
+		var list strings.Builder
+		for _, v := range violations {
+			list.WriteString("- " + v.String() + "\n")
+		}
+		repairPrompt, err := repairTmpl.Render(prompt.RepairData{
+			Violations: list.String(),
+			Language:   targetLang,
+			Prompt:     promptText,
+			Response:   jsonStr,
+		})
+		if err != nil {
+			return jsonStr, violations, attempts, err
+		}
+
+		repaired, response, err := llm.GenerateJSON(ctx, client, repairPrompt, llm.EtymologySchema(kind))
+		if err != nil && response == "" {
+			return jsonStr, violations, attempts, err
+		}
+		if err != nil {
+			// Keep the previous response and its violations; try again if attempts remain
+			log.Printf("Repair attempt %d returned unparseable JSON: %v", attempts, err)
+			continue
+		}
+
+		jsonStr = repaired
+		violations = llm.ValidateEtymology(jsonStr, kind, targetLang)
+	}
+
+	return jsonStr, violations, attempts, nil
 }
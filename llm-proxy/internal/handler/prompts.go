@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/epikoding/etymograph/llm-proxy/internal/prompt"
+	"github.com/gin-gonic/gin"
+)
+
+type PromptsHandler struct {
+	prompts *prompt.Registry
+}
+
+func NewPromptsHandler(prompts *prompt.Registry) *PromptsHandler {
+	return &PromptsHandler{prompts: prompts}
+}
+
+// List returns every loaded prompt version and which one is active
+func (h *PromptsHandler) List(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"prompts": h.prompts.List()})
+}
+
+// setPromptHeaders reports the prompt template that produced a response
+func setPromptHeaders(c *gin.Context, tmpl *prompt.Template) {
+	c.Header("X-Prompt-Id", tmpl.ID)
+	c.Header("X-Prompt-Version", strconv.Itoa(tmpl.Version))
+}
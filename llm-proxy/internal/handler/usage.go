@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/epikoding/etymograph/llm-proxy/internal/usage"
+	"github.com/gin-gonic/gin"
+)
+
+type UsageHandler struct {
+	tracker *usage.Tracker
+}
+
+func NewUsageHandler(tracker *usage.Tracker) *UsageHandler {
+	return &UsageHandler{tracker: tracker}
+}
+
+// Summary returns token usage and estimated cost for ?date=YYYY-MM-DD (UTC), default today
+func (h *UsageHandler) Summary(c *gin.Context) {
+	date := c.Query("date")
+	if date == "" {
+		c.JSON(http.StatusOK, h.tracker.Today())
+		return
+	}
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		c.JSON(invalidRequestBody("date must be YYYY-MM-DD"))
+		return
+	}
+	c.JSON(http.StatusOK, h.tracker.Summary(date))
+}
@@ -0,0 +1,141 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// anthropicVersion is the Messages API version sent with every request
+const anthropicVersion = "2023-06-01"
+
+// AnthropicClient for the Anthropic Messages wire format
+type AnthropicClient struct {
+	baseURL    string
+	apiKey     string
+	model      string
+	maxTokens  int
+	httpClient *http.Client
+}
+
+type AnthropicRequest struct {
+	Model     string             `json:"model"`
+	MaxTokens int                `json:"max_tokens"`
+	Messages  []AnthropicMessage `json:"messages"`
+}
+
+type AnthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type AnthropicResponse struct {
+	Content    []AnthropicContentBlock `json:"content"`
+	StopReason string                  `json:"stop_reason"`
+	Usage      *AnthropicUsage         `json:"usage,omitempty"`
+	Error      *AnthropicError         `json:"error,omitempty"`
+}
+
+type AnthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+type AnthropicContentBlock struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type AnthropicError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// NewAnthropicClient creates a client for baseURL (e.g. "https://api.anthropic.com").
+// maxTokens caps the length of each response, as the Messages API requires.
+func NewAnthropicClient(baseURL, apiKey, model string, maxTokens int) *AnthropicClient {
+	return &AnthropicClient{
+		baseURL:   strings.TrimRight(baseURL, "/"),
+		apiKey:    apiKey,
+		model:     model,
+		maxTokens: maxTokens,
+		httpClient: &http.Client{
+			Timeout: 120 * time.Second,
+		},
+	}
+}
+
+func (c *AnthropicClient) Generate(ctx context.Context, prompt string) (string, error) {
+	reqBody := AnthropicRequest{
+		Model:     c.model,
+		MaxTokens: c.maxTokens,
+		Messages: []AnthropicMessage{
+			{Role: "user", Content: prompt},
+		},
+	}
+
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/v1/messages", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", c.apiKey)
+	req.Header.Set("anthropic-version", anthropicVersion)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", newStatusError("anthropic", resp, body)
+	}
+
+	var msgResp AnthropicResponse
+	if err := json.Unmarshal(body, &msgResp); err != nil {
+		return "", fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if msgResp.Error != nil {
+		return "", fmt.Errorf("anthropic error: %s", msgResp.Error.Message)
+	}
+	if msgResp.Usage != nil {
+		RecordUsage(ctx, Usage{Provider: "anthropic", Model: c.model, PromptTokens: msgResp.Usage.InputTokens, CompletionTokens: msgResp.Usage.OutputTokens})
+	}
+
+	switch msgResp.StopReason {
+	case "refusal":
+		return "", &BlockedError{Provider: "anthropic", Reason: "refusal"}
+	case "max_tokens":
+		return "", &TruncatedError{Provider: "anthropic", Reason: "max_tokens"}
+	}
+
+	// Concatenate text blocks; other block types are not requested
+	var text strings.Builder
+	for _, block := range msgResp.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	if text.Len() == 0 {
+		return "", fmt.Errorf("no response from anthropic")
+	}
+
+	return text.String(), nil
+}
@@ -0,0 +1,142 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// anthropicServer stands in for the Messages endpoint, capturing each request's
+// headers and body and answering with status and response
+func anthropicServer(t *testing.T, status int, response string, header *http.Header, body *map[string]interface{}) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" {
+			t.Errorf("path = %s, want /v1/messages", r.URL.Path)
+		}
+		if header != nil {
+			*header = r.Header.Clone()
+		}
+		if body != nil {
+			data, _ := io.ReadAll(r.Body)
+			if err := json.Unmarshal(data, body); err != nil {
+				t.Errorf("request body is not JSON: %v", err)
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, response)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestAnthropicGenerate(t *testing.T) {
+	var header http.Header
+	var body map[string]interface{}
+	response := `{"content":[{"type":"text","text":"{\"word\":"},{"type":"text","text":"\"test\"}"}],"stop_reason":"end_turn","usage":{"input_tokens":21,"output_tokens":8}}`
+	srv := anthropicServer(t, http.StatusOK, response, &header, &body)
+
+	var usage Usage
+	ctx := WithUsageRecorder(context.Background(), func(u Usage) { usage = u })
+	client := NewAnthropicClient(srv.URL+"/", "ak-test", "claude-test", 1024)
+	text, err := client.Generate(ctx, "hello")
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if text != `{"word":"test"}` {
+		t.Errorf("text = %q, want the text blocks joined", text)
+	}
+
+	if got := header.Get("x-api-key"); got != "ak-test" {
+		t.Errorf("x-api-key = %q", got)
+	}
+	if got := header.Get("anthropic-version"); got != anthropicVersion {
+		t.Errorf("anthropic-version = %q, want %s", got, anthropicVersion)
+	}
+	if got := header.Get("Authorization"); got != "" {
+		t.Errorf("Authorization = %q, want none", got)
+	}
+	if body["model"] != "claude-test" {
+		t.Errorf("model = %v", body["model"])
+	}
+	if body["max_tokens"] != float64(1024) {
+		t.Errorf("max_tokens = %v, want 1024", body["max_tokens"])
+	}
+	messages, _ := body["messages"].([]interface{})
+	if len(messages) != 1 {
+		t.Fatalf("messages = %v", body["messages"])
+	}
+	if msg := messages[0].(map[string]interface{}); msg["role"] != "user" || msg["content"] != "hello" {
+		t.Errorf("message = %v", msg)
+	}
+
+	want := Usage{Provider: "anthropic", Model: "claude-test", PromptTokens: 21, CompletionTokens: 8}
+	if usage != want {
+		t.Errorf("usage = %+v, want %+v", usage, want)
+	}
+}
+
+func TestAnthropicStatusError(t *testing.T) {
+	body := `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`
+	srv := anthropicServer(t, 529, body, nil, nil)
+
+	_, err := NewAnthropicClient(srv.URL, "ak-test", "claude-test", 1024).Generate(context.Background(), "hello")
+	var se *StatusError
+	if !errors.As(err, &se) {
+		t.Fatalf("err = %v, want *StatusError", err)
+	}
+	if se.Provider != "anthropic" || se.StatusCode != 529 || se.Body != body {
+		t.Errorf("StatusError = %+v", se)
+	}
+	if f := Classify(err); f.Code != CodeProviderUnavailable {
+		t.Errorf("code = %s, want %s", f.Code, CodeProviderUnavailable)
+	}
+	if !IsRetryable(err) {
+		t.Error("overloaded response should be retryable")
+	}
+}
+
+func TestAnthropicStopReasons(t *testing.T) {
+	tests := []struct {
+		reason string
+		check  func(t *testing.T, err error)
+	}{
+		{"refusal", func(t *testing.T, err error) {
+			var blocked *BlockedError
+			if !errors.As(err, &blocked) || blocked.Provider != "anthropic" || blocked.Reason != "refusal" {
+				t.Errorf("err = %v, want *BlockedError", err)
+			}
+			if f := Classify(err); f.Code != CodeContentBlocked {
+				t.Errorf("code = %s, want %s", f.Code, CodeContentBlocked)
+			}
+		}},
+		{"max_tokens", func(t *testing.T, err error) {
+			var truncated *TruncatedError
+			if !errors.As(err, &truncated) || truncated.Provider != "anthropic" {
+				t.Errorf("err = %v, want *TruncatedError", err)
+			}
+			if f := Classify(err); f.Code != CodeBadOutput {
+				t.Errorf("code = %s, want %s", f.Code, CodeBadOutput)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.reason, func(t *testing.T) {
+			response := `{"content":[{"type":"text","text":"{\"word\":"}],"stop_reason":"` + tt.reason + `","usage":{"input_tokens":5,"output_tokens":9}}`
+			srv := anthropicServer(t, http.StatusOK, response, nil, nil)
+
+			recorded := false
+			ctx := WithUsageRecorder(context.Background(), func(Usage) { recorded = true })
+			_, err := NewAnthropicClient(srv.URL, "ak-test", "claude-test", 1024).Generate(ctx, "hello")
+			tt.check(t, err)
+			if !recorded {
+				t.Error("usage of a rejected response was not recorded")
+			}
+		})
+	}
+}
@@ -28,13 +28,17 @@ type OllamaGenerateRequest struct {
 	Model  string `json:"model"`
 	Prompt string `json:"prompt"`
 	Stream bool   `json:"stream"`
+	// Format is "json" or a JSON schema the response must follow
+	Format interface{} `json:"format,omitempty"`
 }
 
 type OllamaGenerateResponse struct {
-	Model     string `json:"model"`
-	Response  string `json:"response"`
-	Done      bool   `json:"done"`
-	CreatedAt string `json:"created_at"`
+	Model           string `json:"model"`
+	Response        string `json:"response"`
+	Done            bool   `json:"done"`
+	CreatedAt       string `json:"created_at"`
+	PromptEvalCount int    `json:"prompt_eval_count"`
+	EvalCount       int    `json:"eval_count"`
 }
 
 func NewOllamaClient(baseURL, model string) *OllamaClient {
@@ -48,10 +52,23 @@ func NewOllamaClient(baseURL, model string) *OllamaClient {
 }
 
 func (c *OllamaClient) Generate(ctx context.Context, prompt string) (string, error) {
+	return c.generate(ctx, prompt, nil)
+}
+
+// GenerateJSON uses Ollama structured outputs, constraining the response to schema
+func (c *OllamaClient) GenerateJSON(ctx context.Context, prompt string, schema *Schema) (string, error) {
+	if schema == nil {
+		return c.generate(ctx, prompt, "json")
+	}
+	return c.generate(ctx, prompt, schema)
+}
+
+func (c *OllamaClient) generate(ctx context.Context, prompt string, format interface{}) (string, error) {
 	reqBody := OllamaGenerateRequest{
 		Model:  c.model,
 		Prompt: prompt,
 		Stream: false,
+		Format: format,
 	}
 
 	jsonBody, err := json.Marshal(reqBody)
@@ -73,7 +90,7 @@ func (c *OllamaClient) Generate(ctx context.Context, prompt string) (string, err
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("ollama returned status %d: %s", resp.StatusCode, string(body))
+		return "", newStatusError("ollama", resp, body)
 	}
 
 	body, err := io.ReadAll(resp.Body)
@@ -85,6 +102,7 @@ func (c *OllamaClient) Generate(ctx context.Context, prompt string) (string, err
 	if err := json.Unmarshal(body, &genResp); err != nil {
 		return "", fmt.Errorf("failed to unmarshal response: %w", err)
 	}
+	RecordUsage(ctx, Usage{Provider: "ollama", Model: c.model, PromptTokens: genResp.PromptEvalCount, CompletionTokens: genResp.EvalCount})
 
 	return genResp.Response, nil
 }
@@ -97,7 +115,13 @@ type GeminiClient struct {
 }
 
 type GeminiRequest struct {
-	Contents []GeminiContent `json:"contents"`
+	Contents         []GeminiContent         `json:"contents"`
+	GenerationConfig *GeminiGenerationConfig `json:"generationConfig,omitempty"`
+}
+
+type GeminiGenerationConfig struct {
+	ResponseMimeType string        `json:"responseMimeType,omitempty"`
+	ResponseSchema   *GeminiSchema `json:"responseSchema,omitempty"`
 }
 
 type GeminiContent struct {
@@ -109,12 +133,53 @@ type GeminiPart struct {
 }
 
 type GeminiResponse struct {
-	Candidates []GeminiCandidate `json:"candidates"`
-	Error      *GeminiError      `json:"error,omitempty"`
+	Candidates     []GeminiCandidate     `json:"candidates"`
+	PromptFeedback *GeminiPromptFeedback `json:"promptFeedback,omitempty"`
+	UsageMetadata  *GeminiUsageMetadata  `json:"usageMetadata,omitempty"`
+	Error          *GeminiError          `json:"error,omitempty"`
+}
+
+type GeminiPromptFeedback struct {
+	BlockReason string `json:"blockReason"`
+}
+
+// geminiBlockFinishReasons are finish reasons meaning the candidate was withheld
+var geminiBlockFinishReasons = map[string]bool{
+	"SAFETY":             true,
+	"RECITATION":         true,
+	"BLOCKLIST":          true,
+	"PROHIBITED_CONTENT": true,
+	"SPII":               true,
+}
+
+// blocked returns a BlockedError if Gemini withheld the prompt or its first candidate
+func (r *GeminiResponse) blocked() error {
+	if r.PromptFeedback != nil && r.PromptFeedback.BlockReason != "" {
+		return &BlockedError{Provider: "gemini", Reason: r.PromptFeedback.BlockReason}
+	}
+	if len(r.Candidates) > 0 && geminiBlockFinishReasons[r.Candidates[0].FinishReason] {
+		return &BlockedError{Provider: "gemini", Reason: r.Candidates[0].FinishReason}
+	}
+	return nil
+}
+
+type GeminiUsageMetadata struct {
+	PromptTokenCount     int `json:"promptTokenCount"`
+	CandidatesTokenCount int `json:"candidatesTokenCount"`
+	TotalTokenCount      int `json:"totalTokenCount"`
+}
+
+// usage converts Gemini's usage metadata; nil means the response carried none
+func (m *GeminiUsageMetadata) usage(model string) Usage {
+	if m == nil {
+		return Usage{Provider: "gemini", Model: model}
+	}
+	return Usage{Provider: "gemini", Model: model, PromptTokens: m.PromptTokenCount, CompletionTokens: m.CandidatesTokenCount}
 }
 
 type GeminiCandidate struct {
-	Content GeminiContent `json:"content"`
+	Content      GeminiContent `json:"content"`
+	FinishReason string        `json:"finishReason,omitempty"`
 }
 
 type GeminiError struct {
@@ -133,6 +198,18 @@ func NewGeminiClient(apiKey, model string) *GeminiClient {
 }
 
 func (c *GeminiClient) Generate(ctx context.Context, prompt string) (string, error) {
+	return c.generate(ctx, prompt, nil)
+}
+
+// GenerateJSON uses Gemini's JSON mode, constraining the response to schema
+func (c *GeminiClient) GenerateJSON(ctx context.Context, prompt string, schema *Schema) (string, error) {
+	return c.generate(ctx, prompt, &GeminiGenerationConfig{
+		ResponseMimeType: "application/json",
+		ResponseSchema:   schema.Gemini(),
+	})
+}
+
+func (c *GeminiClient) generate(ctx context.Context, prompt string, genConfig *GeminiGenerationConfig) (string, error) {
 	reqBody := GeminiRequest{
 		Contents: []GeminiContent{
 			{
@@ -141,6 +218,7 @@ func (c *GeminiClient) Generate(ctx context.Context, prompt string) (string, err
 				},
 			},
 		},
+		GenerationConfig: genConfig,
 	}
 
 	jsonBody, err := json.Marshal(reqBody)
@@ -167,7 +245,7 @@ func (c *GeminiClient) Generate(ctx context.Context, prompt string) (string, err
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("gemini returned status %d: %s", resp.StatusCode, string(body))
+		return "", newStatusError("gemini", resp, body)
 	}
 
 	var genResp GeminiResponse
@@ -178,7 +256,11 @@ func (c *GeminiClient) Generate(ctx context.Context, prompt string) (string, err
 	if genResp.Error != nil {
 		return "", fmt.Errorf("gemini error: %s", genResp.Error.Message)
 	}
+	RecordUsage(ctx, genResp.UsageMetadata.usage(c.model))
 
+	if err := genResp.blocked(); err != nil {
+		return "", err
+	}
 	if len(genResp.Candidates) == 0 || len(genResp.Candidates[0].Content.Parts) == 0 {
 		return "", fmt.Errorf("no response from gemini")
 	}
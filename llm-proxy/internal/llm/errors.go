@@ -0,0 +1,191 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StatusError is returned when a provider answers with a non-200 status
+type StatusError struct {
+	Provider   string
+	StatusCode int
+	Body       string
+	// RetryAfter is the provider's requested wait (Retry-After header or Gemini's
+	// retryDelay), zero if it gave none
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s returned status %d: %s", e.Provider, e.StatusCode, e.Body)
+}
+
+// retryDelayPattern matches the retryDelay in Gemini's RetryInfo error details
+var retryDelayPattern = regexp.MustCompile(`"retryDelay":\s*"([0-9.]+)s"`)
+
+// newStatusError builds a StatusError from a provider's non-200 response
+func newStatusError(provider string, resp *http.Response, body []byte) *StatusError {
+	e := &StatusError{Provider: provider, StatusCode: resp.StatusCode, Body: string(body)}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		e.RetryAfter = time.Duration(seconds) * time.Second
+	} else if m := retryDelayPattern.FindSubmatch(body); m != nil {
+		if seconds, err := strconv.ParseFloat(string(m[1]), 64); err == nil {
+			e.RetryAfter = time.Duration(seconds * float64(time.Second))
+		}
+	}
+	return e
+}
+
+// BlockedError is returned when a provider refuses to answer for safety or policy reasons
+type BlockedError struct {
+	Provider string
+	Reason   string
+}
+
+func (e *BlockedError) Error() string {
+	return fmt.Sprintf("%s blocked the response: %s", e.Provider, e.Reason)
+}
+
+// TruncatedError is returned when a provider stopped at its output token limit,
+// leaving a response that is cut off mid-way
+type TruncatedError struct {
+	Provider string
+	Reason   string
+}
+
+func (e *TruncatedError) Error() string {
+	return fmt.Sprintf("%s truncated the response: %s", e.Provider, e.Reason)
+}
+
+// IsQuotaError reports whether err means the provider rejected the request for
+// rate limit or quota reasons (HTTP 429 or Gemini's RESOURCE_EXHAUSTED)
+func IsQuotaError(err error) bool {
+	var se *StatusError
+	if errors.As(err, &se) {
+		return se.StatusCode == http.StatusTooManyRequests || strings.Contains(se.Body, "RESOURCE_EXHAUSTED")
+	}
+	return false
+}
+
+// IsTimeout reports whether err is a timeout talking to the provider
+func IsTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
+
+// IsRetryable reports whether another provider may succeed where this one failed:
+// quota errors (including used-up quota or credit), timeouts, 5xx responses, auth and
+// unknown-model errors (the provider is misconfigured) and connection failures.
+// Other 4xx responses mean the request itself is bad and are not retried.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if IsQuotaError(err) || IsTimeout(err) {
+		return true
+	}
+	var se *StatusError
+	if errors.As(err, &se) {
+		return se.StatusCode >= 500 || isQuotaExhausted(se) || isMisconfigured(se)
+	}
+	var ne net.Error
+	return errors.As(err, &ne)
+}
+
+// Error codes of llm-proxy error responses ({"error", "code", ...})
+const (
+	CodeRateLimited         = "RATE_LIMITED"          // provider rate limit; retry after retryAfter seconds
+	CodeQuotaExhausted      = "QUOTA_EXHAUSTED"       // provider quota or credit used up
+	CodeTimeout             = "TIMEOUT"               // provider or caller deadline exceeded
+	CodeBadOutput           = "BAD_OUTPUT"            // response was not the requested JSON
+	CodeSchemaViolation     = "SCHEMA_VIOLATION"      // response broke the prompt rules after repair
+	CodeContentBlocked      = "CONTENT_BLOCKED"       // provider refused for safety or policy reasons
+	CodeProviderUnavailable = "PROVIDER_UNAVAILABLE"  // provider down, overloaded or unreachable
+	CodeBudgetExceeded      = "DAILY_BUDGET_EXCEEDED" // llm-proxy's own daily budget reached
+	CodeCancelled           = "CANCELLED"             // the caller went away
+	CodeInvalidRequest      = "INVALID_REQUEST"
+	CodeInternal            = "INTERNAL_ERROR"
+)
+
+// Failure is a provider error classified for the error response
+type Failure struct {
+	Status     int
+	Code       string
+	Provider   string
+	RetryAfter time.Duration
+}
+
+// quotaPatterns mark 429s and 4xx responses that will not clear within seconds:
+// Gemini per-day limits, OpenAI insufficient_quota and Anthropic credit errors
+var quotaPatterns = []string{"PerDay", "per day", "insufficient_quota", "credit balance", "billing"}
+
+// Classify maps a generation error to its error code and HTTP status
+func Classify(err error) Failure {
+	var blocked *BlockedError
+	if errors.As(err, &blocked) {
+		return Failure{Status: http.StatusUnprocessableEntity, Code: CodeContentBlocked, Provider: blocked.Provider}
+	}
+	var truncated *TruncatedError
+	if errors.As(err, &truncated) {
+		return Failure{Status: http.StatusBadGateway, Code: CodeBadOutput, Provider: truncated.Provider}
+	}
+
+	var se *StatusError
+	if errors.As(err, &se) {
+		f := Failure{Provider: se.Provider, RetryAfter: se.RetryAfter}
+		switch {
+		case isQuotaExhausted(se):
+			f.Status, f.Code = http.StatusTooManyRequests, CodeQuotaExhausted
+		case IsQuotaError(se):
+			f.Status, f.Code = http.StatusTooManyRequests, CodeRateLimited
+		case se.StatusCode >= 500, isMisconfigured(se):
+			// A misconfigured provider is down as far as callers are concerned
+			f.Status, f.Code = http.StatusServiceUnavailable, CodeProviderUnavailable
+		default:
+			f.Status, f.Code = http.StatusBadGateway, CodeInternal
+		}
+		return f
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return Failure{Status: 499, Code: CodeCancelled}
+	case IsTimeout(err):
+		return Failure{Status: http.StatusGatewayTimeout, Code: CodeTimeout}
+	}
+	var ne net.Error
+	if errors.As(err, &ne) {
+		return Failure{Status: http.StatusServiceUnavailable, Code: CodeProviderUnavailable}
+	}
+	return Failure{Status: http.StatusInternalServerError, Code: CodeInternal}
+}
+
+// isMisconfigured reports auth and unknown-model errors: the provider's key or model
+// setting is wrong, not the request
+func isMisconfigured(se *StatusError) bool {
+	return se.StatusCode == http.StatusUnauthorized || se.StatusCode == http.StatusForbidden ||
+		se.StatusCode == http.StatusNotFound
+}
+
+// isQuotaExhausted reports quota or credit errors that will not clear within seconds
+func isQuotaExhausted(se *StatusError) bool {
+	if se.StatusCode != http.StatusTooManyRequests && se.StatusCode != http.StatusPaymentRequired &&
+		se.StatusCode != http.StatusBadRequest && se.StatusCode != http.StatusForbidden {
+		return false
+	}
+	for _, pattern := range quotaPatterns {
+		if strings.Contains(se.Body, pattern) {
+			return true
+		}
+	}
+	return false
+}
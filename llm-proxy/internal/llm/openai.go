@@ -0,0 +1,156 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OpenAIClient for the OpenAI chat completions wire format.
+// Any compatible server works by changing the base URL
+// (vLLM, llama.cpp server, LM Studio, ...).
+type OpenAIClient struct {
+	baseURL    string
+	apiKey     string
+	model      string
+	httpClient *http.Client
+}
+
+type OpenAIChatRequest struct {
+	Model          string                `json:"model"`
+	Messages       []OpenAIMessage       `json:"messages"`
+	ResponseFormat *OpenAIResponseFormat `json:"response_format,omitempty"`
+}
+
+// OpenAIResponseFormat selects JSON mode: "json_object", or "json_schema" with a schema
+type OpenAIResponseFormat struct {
+	Type       string            `json:"type"`
+	JSONSchema *OpenAIJSONSchema `json:"json_schema,omitempty"`
+}
+
+type OpenAIJSONSchema struct {
+	Name   string  `json:"name"`
+	Schema *Schema `json:"schema"`
+}
+
+type OpenAIMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type OpenAIChatResponse struct {
+	Choices []OpenAIChoice `json:"choices"`
+	Usage   *OpenAIUsage   `json:"usage,omitempty"`
+	Error   *OpenAIError   `json:"error,omitempty"`
+}
+
+type OpenAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+type OpenAIChoice struct {
+	Message      OpenAIMessage `json:"message"`
+	FinishReason string        `json:"finish_reason"`
+}
+
+type OpenAIError struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+}
+
+// NewOpenAIClient creates a client for baseURL (e.g. "https://api.openai.com/v1").
+// apiKey may be empty for local servers that do not check it.
+func NewOpenAIClient(baseURL, apiKey, model string) *OpenAIClient {
+	return &OpenAIClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		model:   model,
+		httpClient: &http.Client{
+			Timeout: 120 * time.Second,
+		},
+	}
+}
+
+func (c *OpenAIClient) Generate(ctx context.Context, prompt string) (string, error) {
+	return c.generate(ctx, prompt, nil)
+}
+
+// GenerateJSON uses the response_format JSON mode, constraining the response to schema
+func (c *OpenAIClient) GenerateJSON(ctx context.Context, prompt string, schema *Schema) (string, error) {
+	if schema == nil {
+		return c.generate(ctx, prompt, &OpenAIResponseFormat{Type: "json_object"})
+	}
+	return c.generate(ctx, prompt, &OpenAIResponseFormat{
+		Type:       "json_schema",
+		JSONSchema: &OpenAIJSONSchema{Name: "response", Schema: schema},
+	})
+}
+
+func (c *OpenAIClient) generate(ctx context.Context, prompt string, format *OpenAIResponseFormat) (string, error) {
+	reqBody := OpenAIChatRequest{
+		Model: c.model,
+		Messages: []OpenAIMessage{
+			{Role: "user", Content: prompt},
+		},
+		ResponseFormat: format,
+	}
+
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/chat/completions", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", newStatusError("openai", resp, body)
+	}
+
+	var chatResp OpenAIChatResponse
+	if err := json.Unmarshal(body, &chatResp); err != nil {
+		return "", fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if chatResp.Error != nil {
+		return "", fmt.Errorf("openai error: %s", chatResp.Error.Message)
+	}
+	if chatResp.Usage != nil {
+		RecordUsage(ctx, Usage{Provider: "openai", Model: c.model, PromptTokens: chatResp.Usage.PromptTokens, CompletionTokens: chatResp.Usage.CompletionTokens})
+	}
+
+	if len(chatResp.Choices) == 0 {
+		return "", fmt.Errorf("no response from openai")
+	}
+	switch chatResp.Choices[0].FinishReason {
+	case "content_filter":
+		return "", &BlockedError{Provider: "openai", Reason: "content_filter"}
+	case "length":
+		return "", &TruncatedError{Provider: "openai", Reason: "length"}
+	}
+
+	return chatResp.Choices[0].Message.Content, nil
+}
@@ -0,0 +1,192 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// openAIServer stands in for a chat completions endpoint, capturing each request's
+// headers and body and answering with status and response
+func openAIServer(t *testing.T, status int, response string, header *http.Header, body *map[string]interface{}) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("path = %s, want /v1/chat/completions", r.URL.Path)
+		}
+		if header != nil {
+			*header = r.Header.Clone()
+		}
+		if body != nil {
+			data, _ := io.ReadAll(r.Body)
+			if err := json.Unmarshal(data, body); err != nil {
+				t.Errorf("request body is not JSON: %v", err)
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, response)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+const openAIOK = `{"choices":[{"message":{"role":"assistant","content":"{\"word\":\"test\"}"},"finish_reason":"stop"}],"usage":{"prompt_tokens":12,"completion_tokens":34}}`
+
+func TestOpenAIGenerate(t *testing.T) {
+	var header http.Header
+	var body map[string]interface{}
+	srv := openAIServer(t, http.StatusOK, openAIOK, &header, &body)
+
+	var usage Usage
+	ctx := WithUsageRecorder(context.Background(), func(u Usage) { usage = u })
+	client := NewOpenAIClient(srv.URL+"/v1/", "sk-test", "gpt-test")
+	text, err := client.Generate(ctx, "hello")
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if text != `{"word":"test"}` {
+		t.Errorf("text = %q", text)
+	}
+
+	if got := header.Get("Authorization"); got != "Bearer sk-test" {
+		t.Errorf("Authorization = %q, want bearer token", got)
+	}
+	if body["model"] != "gpt-test" {
+		t.Errorf("model = %v", body["model"])
+	}
+	messages, _ := body["messages"].([]interface{})
+	if len(messages) != 1 {
+		t.Fatalf("messages = %v", body["messages"])
+	}
+	if msg := messages[0].(map[string]interface{}); msg["role"] != "user" || msg["content"] != "hello" {
+		t.Errorf("message = %v", msg)
+	}
+	if _, ok := body["response_format"]; ok {
+		t.Errorf("plain Generate sent response_format %v", body["response_format"])
+	}
+
+	want := Usage{Provider: "openai", Model: "gpt-test", PromptTokens: 12, CompletionTokens: 34}
+	if usage != want {
+		t.Errorf("usage = %+v, want %+v", usage, want)
+	}
+}
+
+func TestOpenAIGenerateWithoutKey(t *testing.T) {
+	var header http.Header
+	srv := openAIServer(t, http.StatusOK, openAIOK, &header, nil)
+
+	if _, err := NewOpenAIClient(srv.URL+"/v1", "", "local").Generate(context.Background(), "hello"); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if got := header.Get("Authorization"); got != "" {
+		t.Errorf("Authorization = %q, want none for local servers", got)
+	}
+}
+
+func TestOpenAIGenerateJSON(t *testing.T) {
+	var body map[string]interface{}
+	srv := openAIServer(t, http.StatusOK, openAIOK, nil, &body)
+	client := NewOpenAIClient(srv.URL+"/v1", "sk-test", "gpt-test")
+
+	if _, err := client.GenerateJSON(context.Background(), "hello", nil); err != nil {
+		t.Fatalf("GenerateJSON: %v", err)
+	}
+	format, _ := body["response_format"].(map[string]interface{})
+	if format["type"] != "json_object" {
+		t.Errorf("response_format = %v, want json_object", body["response_format"])
+	}
+
+	if _, err := client.GenerateJSON(context.Background(), "hello", EtymologySchema(EtymologyKindWord)); err != nil {
+		t.Fatalf("GenerateJSON: %v", err)
+	}
+	format, _ = body["response_format"].(map[string]interface{})
+	if format["type"] != "json_schema" {
+		t.Fatalf("response_format = %v, want json_schema", body["response_format"])
+	}
+	jsonSchema, _ := format["json_schema"].(map[string]interface{})
+	schema, _ := jsonSchema["schema"].(map[string]interface{})
+	if schema["type"] != "object" || schema["properties"] == nil {
+		t.Errorf("json_schema = %v, want the etymology schema", jsonSchema)
+	}
+}
+
+func TestOpenAIStatusError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "7")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = io.WriteString(w, `{"error":{"message":"rate limited","type":"rate_limit_error"}}`)
+	}))
+	defer srv.Close()
+
+	_, err := NewOpenAIClient(srv.URL, "sk-test", "gpt-test").Generate(context.Background(), "hello")
+	var se *StatusError
+	if !errors.As(err, &se) {
+		t.Fatalf("err = %v, want *StatusError", err)
+	}
+	if se.Provider != "openai" || se.StatusCode != http.StatusTooManyRequests || se.RetryAfter.Seconds() != 7 {
+		t.Errorf("StatusError = %+v", se)
+	}
+	if f := Classify(err); f.Code != CodeRateLimited {
+		t.Errorf("code = %s, want %s", f.Code, CodeRateLimited)
+	}
+}
+
+func TestOpenAIFinishReasons(t *testing.T) {
+	tests := []struct {
+		reason string
+		check  func(t *testing.T, err error)
+	}{
+		{"content_filter", func(t *testing.T, err error) {
+			var blocked *BlockedError
+			if !errors.As(err, &blocked) || blocked.Provider != "openai" || blocked.Reason != "content_filter" {
+				t.Errorf("err = %v, want *BlockedError", err)
+			}
+		}},
+		{"length", func(t *testing.T, err error) {
+			var truncated *TruncatedError
+			if !errors.As(err, &truncated) || truncated.Provider != "openai" {
+				t.Errorf("err = %v, want *TruncatedError", err)
+			}
+			if f := Classify(err); f.Code != CodeBadOutput {
+				t.Errorf("code = %s, want %s", f.Code, CodeBadOutput)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.reason, func(t *testing.T) {
+			response := `{"choices":[{"message":{"role":"assistant","content":"{\"word\":"},"finish_reason":"` + tt.reason + `"}],"usage":{"prompt_tokens":5,"completion_tokens":9}}`
+			srv := openAIServer(t, http.StatusOK, response, nil, nil)
+
+			recorded := false
+			ctx := WithUsageRecorder(context.Background(), func(Usage) { recorded = true })
+			_, err := NewOpenAIClient(srv.URL+"/v1", "sk-test", "gpt-test").Generate(ctx, "hello")
+			tt.check(t, err)
+			if !recorded {
+				t.Error("usage of a rejected response was not recorded")
+			}
+		})
+	}
+}
+
+func TestOpenAIGenerateJSONQuotaIsNotRetriedWithoutJSONMode(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, `{"error":{"message":"You exceeded your current quota, please check your plan and billing details.","type":"insufficient_quota"}}`)
+	}))
+	defer srv.Close()
+
+	_, _, err := GenerateJSON(context.Background(), NewOpenAIClient(srv.URL, "sk-test", "gpt-test"), "hello", nil)
+	if f := Classify(err); f.Code != CodeQuotaExhausted {
+		t.Errorf("code = %s, want %s", f.Code, CodeQuotaExhausted)
+	}
+	if requests != 1 {
+		t.Errorf("%d requests, want 1: a quota error is not a JSON mode rejection", requests)
+	}
+}
@@ -0,0 +1,75 @@
+package llm
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// RetryClient retries quota errors (429, RESOURCE_EXHAUSTED) with exponential backoff.
+// Batch requests use it so one word hitting a rate limit waits instead of failing.
+type RetryClient struct {
+	client    LLMClient
+	attempts  int
+	baseDelay time.Duration
+}
+
+// NewRetryClient makes up to attempts calls, waiting baseDelay, 2*baseDelay, ... between them
+func NewRetryClient(client LLMClient, attempts int, baseDelay time.Duration) *RetryClient {
+	if attempts < 1 {
+		attempts = 1
+	}
+	return &RetryClient{client: client, attempts: attempts, baseDelay: baseDelay}
+}
+
+func (c *RetryClient) Generate(ctx context.Context, prompt string) (string, error) {
+	return c.retry(ctx, func() (string, error) {
+		return c.client.Generate(ctx, prompt)
+	})
+}
+
+// GenerateJSON retries the wrapped client's JSON mode (or plain Generate without one)
+func (c *RetryClient) GenerateJSON(ctx context.Context, prompt string, schema *Schema) (string, error) {
+	return c.retry(ctx, func() (string, error) {
+		return generateJSON(ctx, c.client, prompt, schema)
+	})
+}
+
+func (c *RetryClient) retry(ctx context.Context, generate func() (string, error)) (string, error) {
+	delay := c.baseDelay
+	for attempt := 1; ; attempt++ {
+		response, err := generate()
+		if err == nil || attempt >= c.attempts || !IsQuotaError(err) {
+			return response, err
+		}
+
+		// Wait at least as long as the provider asked
+		wait := delay
+		if f := Classify(err); f.RetryAfter > wait {
+			wait = f.RetryAfter
+		}
+		log.Printf("LLM quota exceeded, retrying in %s (attempt %d/%d)", wait, attempt+1, c.attempts)
+		select {
+		case <-ctx.Done():
+			return "", err
+		case <-time.After(wait):
+		}
+		delay *= 2
+	}
+}
+
+// PrimaryProvider returns the provider client's requests go to first: a RouterClient's
+// Primary, or client itself with a concurrency of 1
+func PrimaryProvider(client LLMClient) Provider {
+	if router, ok := client.(*RouterClient); ok {
+		return router.Primary()
+	}
+	return Provider{Name: "default", Client: client, Concurrency: 1}
+}
+
+// SupportsJSONMode reports whether client (for a RouterClient, its primary provider)
+// can constrain output to a JSON schema, which packing several words into one prompt relies on
+func SupportsJSONMode(client LLMClient) bool {
+	_, ok := PrimaryProvider(client).Client.(JSONClient)
+	return ok
+}
@@ -0,0 +1,257 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	providerAttemptsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "llm_provider_attempts_total",
+			Help: "Total LLM provider attempts by outcome (success, retryable_error, error, skipped)",
+		},
+		[]string{"provider", "outcome"},
+	)
+
+	providerCircuitOpen = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "llm_provider_circuit_open",
+			Help: "Whether the provider's circuit breaker is open (1) or closed (0)",
+		},
+		[]string{"provider"},
+	)
+)
+
+// Provider is a named LLMClient in a RouterClient's priority list.
+// Concurrency is how many batch requests may be sent to it at once.
+type Provider struct {
+	Name        string
+	Client      LLMClient
+	Concurrency int
+}
+
+// ProviderStatus is the health of one provider as tracked by RouterClient
+type ProviderStatus struct {
+	Name                string    `json:"name"`
+	CircuitOpen         bool      `json:"circuitOpen"`
+	ConsecutiveFailures int       `json:"consecutiveFailures"`
+	OpenUntil           time.Time `json:"openUntil,omitempty"`
+	LastError           string    `json:"lastError,omitempty"`
+}
+
+// RouterClient tries providers in priority order and falls back to the next one
+// on retryable errors (quota or credit, timeout, 5xx, auth and unknown-model errors,
+// connection failures).
+// Each provider has a circuit breaker: after failureThreshold consecutive retryable
+// errors it is skipped for cooldown. If every provider's circuit is open they are
+// all tried anyway rather than failing outright.
+type RouterClient struct {
+	providers        []*routedProvider
+	failureThreshold int
+	cooldown         time.Duration
+}
+
+type routedProvider struct {
+	Provider
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	lastErr   string
+}
+
+func NewRouterClient(providers []Provider, failureThreshold int, cooldown time.Duration) *RouterClient {
+	if failureThreshold < 1 {
+		failureThreshold = 1
+	}
+	r := &RouterClient{failureThreshold: failureThreshold, cooldown: cooldown}
+	for _, p := range providers {
+		r.providers = append(r.providers, &routedProvider{Provider: p})
+		providerCircuitOpen.WithLabelValues(p.Name).Set(0)
+	}
+	return r
+}
+
+func (r *RouterClient) Generate(ctx context.Context, prompt string) (string, error) {
+	return r.route(ctx, func(p Provider) (string, error) {
+		return p.Client.Generate(ctx, prompt)
+	}, nil)
+}
+
+// GenerateJSON routes like Generate, using each provider's JSON mode where it has one
+func (r *RouterClient) GenerateJSON(ctx context.Context, prompt string, schema *Schema) (string, error) {
+	return r.route(ctx, func(p Provider) (string, error) {
+		return generateJSON(ctx, p.Client, prompt, schema)
+	}, nil)
+}
+
+// route calls generate with each available provider in priority order until one succeeds.
+// committed (optional) reports whether output has already reached the caller, after
+// which a failure can no longer fall back to another provider.
+func (r *RouterClient) route(ctx context.Context, generate func(p Provider) (string, error), committed func() bool) (string, error) {
+	now := time.Now()
+	candidates := make([]*routedProvider, 0, len(r.providers))
+	for _, p := range r.providers {
+		if p.available(now) {
+			candidates = append(candidates, p)
+		} else {
+			providerAttemptsTotal.WithLabelValues(p.Name, "skipped").Inc()
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = r.providers
+	}
+
+	var errs []error
+	var lastErr error
+	for i, p := range candidates {
+		response, err := generate(p.Provider)
+		if err == nil {
+			p.succeed()
+			providerAttemptsTotal.WithLabelValues(p.Name, "success").Inc()
+			RecordProvider(ctx, p.Name)
+			return response, nil
+		}
+
+		lastErr = err
+		errs = append(errs, fmt.Errorf("%s: %w", p.Name, err))
+
+		// The caller gave up; another provider would not help
+		if ctx.Err() != nil {
+			break
+		}
+		if committed != nil && committed() {
+			providerAttemptsTotal.WithLabelValues(p.Name, "error").Inc()
+			break
+		}
+		if !IsRetryable(err) {
+			providerAttemptsTotal.WithLabelValues(p.Name, "error").Inc()
+			break
+		}
+
+		providerAttemptsTotal.WithLabelValues(p.Name, "retryable_error").Inc()
+		r.fail(p, err)
+		if i < len(candidates)-1 {
+			log.Printf("LLM provider %s failed, falling back to %s: %v", p.Name, candidates[i+1].Name, err)
+		}
+	}
+
+	// A single attempt keeps the provider's error text unchanged
+	if len(errs) == 1 {
+		return "", lastErr
+	}
+	return "", fmt.Errorf("all LLM providers failed: %w", errors.Join(errs...))
+}
+
+// Primary returns the provider that would be tried first right now:
+// the first one whose circuit is closed, or the first one if all are open
+func (r *RouterClient) Primary() Provider {
+	now := time.Now()
+	for _, p := range r.providers {
+		if p.available(now) {
+			return p.Provider
+		}
+	}
+	return r.providers[0].Provider
+}
+
+// Status returns the health of every provider in priority order
+func (r *RouterClient) Status() []ProviderStatus {
+	now := time.Now()
+	status := make([]ProviderStatus, len(r.providers))
+	for i, p := range r.providers {
+		p.mu.Lock()
+		status[i] = ProviderStatus{
+			Name:                p.Name,
+			CircuitOpen:         now.Before(p.openUntil),
+			ConsecutiveFailures: p.failures,
+			LastError:           p.lastErr,
+		}
+		if status[i].CircuitOpen {
+			status[i].OpenUntil = p.openUntil
+		}
+		p.mu.Unlock()
+	}
+	return status
+}
+
+func (r *RouterClient) fail(p *routedProvider, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.failures++
+	p.lastErr = err.Error()
+	if p.failures >= r.failureThreshold {
+		p.openUntil = time.Now().Add(r.cooldown)
+		providerCircuitOpen.WithLabelValues(p.Name).Set(1)
+		log.Printf("LLM provider %s circuit opened for %s after %d consecutive failures", p.Name, r.cooldown, p.failures)
+	}
+}
+
+// available reports whether the circuit is closed or its cooldown has passed (half-open)
+func (p *routedProvider) available(now time.Time) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return !now.Before(p.openUntil)
+}
+
+func (p *routedProvider) succeed() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.failures >= 1 && !p.openUntil.IsZero() {
+		log.Printf("LLM provider %s recovered", p.Name)
+	}
+	p.failures = 0
+	p.openUntil = time.Time{}
+	p.lastErr = ""
+	providerCircuitOpen.WithLabelValues(p.Name).Set(0)
+}
+
+// providerRecorderKey is the context key for the provider that served a request
+type providerRecorderKey struct{}
+
+type providerRecorder struct {
+	mu      sync.Mutex
+	name    string
+	onServe func(name string)
+}
+
+// WithProviderRecorder returns a context in which RouterClient records the provider
+// that served each Generate call. onServe (optional) is called with its name.
+func WithProviderRecorder(ctx context.Context, onServe func(name string)) context.Context {
+	return context.WithValue(ctx, providerRecorderKey{}, &providerRecorder{onServe: onServe})
+}
+
+// ServedProvider returns the provider that served the last Generate call in ctx, if any
+func ServedProvider(ctx context.Context) string {
+	rec, ok := ctx.Value(providerRecorderKey{}).(*providerRecorder)
+	if !ok {
+		return ""
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return rec.name
+}
+
+// RecordProvider records name as the provider that served the request in ctx
+func RecordProvider(ctx context.Context, name string) {
+	rec, ok := ctx.Value(providerRecorderKey{}).(*providerRecorder)
+	if !ok {
+		return
+	}
+	rec.mu.Lock()
+	rec.name = name
+	rec.mu.Unlock()
+	if rec.onServe != nil {
+		rec.onServe(name)
+	}
+}
@@ -0,0 +1,47 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRouterFallsBackWhenProviderIsUnusable(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		code   string
+	}{
+		{"credit exhausted", http.StatusBadRequest, `{"type":"error","error":{"type":"invalid_request_error","message":"Your credit balance is too low to access the Anthropic API."}}`, CodeQuotaExhausted},
+		{"key revoked", http.StatusUnauthorized, `{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`, CodeProviderUnavailable},
+		{"unknown model", http.StatusNotFound, `{"type":"error","error":{"type":"not_found_error","message":"model: claude-test"}}`, CodeProviderUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			anthropic := anthropicServer(t, tt.status, tt.body, nil, nil)
+			openAI := openAIServer(t, http.StatusOK, openAIOK, nil, nil)
+
+			router := NewRouterClient([]Provider{
+				{Name: "anthropic", Client: NewAnthropicClient(anthropic.URL, "ak-test", "claude-test", 1024)},
+				{Name: "openai", Client: NewOpenAIClient(openAI.URL+"/v1", "sk-test", "gpt-test")},
+			}, 3, time.Minute)
+
+			var served string
+			ctx := WithProviderRecorder(context.Background(), func(name string) { served = name })
+			text, err := router.Generate(ctx, "hello")
+			if err != nil {
+				t.Fatalf("Generate: %v", err)
+			}
+			if text != `{"word":"test"}` || served != "openai" {
+				t.Errorf("served %q by %q, want the fallback provider's response", text, served)
+			}
+
+			_, err = NewAnthropicClient(anthropic.URL, "ak-test", "claude-test", 1024).Generate(context.Background(), "hello")
+			if f := Classify(err); f.Code != tt.code {
+				t.Errorf("code = %s, want %s", f.Code, tt.code)
+			}
+		})
+	}
+}
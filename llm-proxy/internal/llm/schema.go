@@ -0,0 +1,277 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// Schema is the subset of JSON Schema understood by both Gemini's responseSchema
+// and Ollama's structured outputs
+type Schema struct {
+	Type       string             `json:"type"`
+	Enum       []string           `json:"enum,omitempty"`
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	Items      *Schema            `json:"items,omitempty"`
+	Required   []string           `json:"required,omitempty"`
+
+	// order keeps properties in struct field order, which the prompts' example JSON follows
+	order []string
+}
+
+// JSONClient is implemented by clients whose provider can constrain output to JSON.
+// A nil schema asks for any JSON object.
+type JSONClient interface {
+	GenerateJSON(ctx context.Context, prompt string, schema *Schema) (string, error)
+}
+
+// GenerateJSON asks client for a JSON object matching schema and extracts it from the response.
+// Clients without a JSON mode, and providers that reject the schema with a 400
+// (other than for quota or credit), fall back to a plain prompt whose JSON is scraped
+// by ExtractJSON.
+// On a parse failure the raw response is returned alongside the error.
+func GenerateJSON(ctx context.Context, client LLMClient, prompt string, schema *Schema) (string, string, error) {
+	response, err := generateJSON(ctx, client, prompt, schema)
+	if err != nil {
+		return "", "", err
+	}
+
+	jsonStr, err := ExtractJSON(response)
+	if err != nil {
+		return "", response, err
+	}
+	return jsonStr, response, nil
+}
+
+// generateJSON uses client's JSON mode when it has one, falling back to Generate
+func generateJSON(ctx context.Context, client LLMClient, prompt string, schema *Schema) (string, error) {
+	jsonClient, ok := client.(JSONClient)
+	if !ok {
+		return client.Generate(ctx, prompt)
+	}
+
+	response, err := jsonClient.GenerateJSON(ctx, prompt, schema)
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusBadRequest && !isQuotaExhausted(statusErr) {
+		log.Printf("%s rejected JSON mode, retrying without it: %v", statusErr.Provider, err)
+		return client.Generate(ctx, prompt)
+	}
+	return response, err
+}
+
+// EtymologySchema returns the response schema for one etymology prompt shape
+func EtymologySchema(kind EtymologyKind) *Schema {
+	switch kind {
+	case EtymologyKindSuffix:
+		return suffixSchema
+	case EtymologyKindPrefix:
+		return prefixSchema
+	default:
+		return wordSchema
+	}
+}
+
+// BatchEtymologySchema is the response schema for the etymology-batch prompt:
+// {"results": [...]} with one word etymology per requested word
+func BatchEtymologySchema() *Schema {
+	return batchSchema
+}
+
+var (
+	batchSchema  = SchemaOf(batchEtymologySchema{})
+	wordSchema   = SchemaOf(wordEtymologySchema{})
+	suffixSchema = SchemaOf(suffixEtymologySchema{})
+	prefixSchema = SchemaOf(prefixEtymologySchema{})
+)
+
+// SchemaOf generates a schema from a struct's json tags.
+// Fields without omitempty are required, and an `enum:"a,b"` tag restricts a string field.
+func SchemaOf(v interface{}) *Schema {
+	return schemaOf(reflect.TypeOf(v))
+}
+
+func schemaOf(t reflect.Type) *Schema {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return schemaOf(t.Elem())
+	case reflect.Struct:
+		s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "" || name == "-" {
+				continue
+			}
+			prop := schemaOf(field.Type)
+			if enum := field.Tag.Get("enum"); enum != "" {
+				prop.Enum = strings.Split(enum, ",")
+			}
+			s.Properties[name] = prop
+			s.order = append(s.order, name)
+			if !strings.Contains(opts, "omitempty") {
+				s.Required = append(s.Required, name)
+			}
+		}
+		return s
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: schemaOf(t.Elem())}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	default:
+		panic(fmt.Sprintf("llm: no schema for %s", t))
+	}
+}
+
+// GeminiSchema is Gemini's OpenAPI-style schema: upper-case type names and
+// an explicit property order, since Gemini otherwise emits keys alphabetically
+type GeminiSchema struct {
+	Type             string                   `json:"type"`
+	Format           string                   `json:"format,omitempty"`
+	Enum             []string                 `json:"enum,omitempty"`
+	Properties       map[string]*GeminiSchema `json:"properties,omitempty"`
+	PropertyOrdering []string                 `json:"propertyOrdering,omitempty"`
+	Items            *GeminiSchema            `json:"items,omitempty"`
+	Required         []string                 `json:"required,omitempty"`
+}
+
+// Gemini converts the schema to Gemini's responseSchema format
+func (s *Schema) Gemini() *GeminiSchema {
+	if s == nil {
+		return nil
+	}
+	g := &GeminiSchema{
+		Type:             strings.ToUpper(s.Type),
+		Enum:             s.Enum,
+		PropertyOrdering: s.order,
+		Items:            s.Items.Gemini(),
+		Required:         s.Required,
+	}
+	if s.Enum != nil {
+		g.Format = "enum"
+	}
+	if len(s.Properties) > 0 {
+		g.Properties = make(map[string]*GeminiSchema, len(s.Properties))
+		for name, prop := range s.Properties {
+			g.Properties[name] = prop.Gemini()
+		}
+	}
+	return g
+}
+
+// =============================================================================
+// Response shapes of EtymologyPrompt, SuffixEtymologyPrompt and PrefixEtymologyPrompt.
+// Required fields are the ones ValidateEtymology and api-go's etymology package rely on.
+// =============================================================================
+
+type wordEtymologySchema struct {
+	Word       string `json:"word"`
+	Definition struct {
+		Brief    string `json:"brief"`
+		Detailed string `json:"detailed,omitempty"`
+		Nuance   string `json:"nuance,omitempty"`
+	} `json:"definition"`
+	Examples []struct {
+		English     string `json:"english"`
+		Translation string `json:"translation"`
+	} `json:"examples,omitempty"`
+	Origin struct {
+		Language    string `json:"language"`
+		Root        string `json:"root"`
+		RootMeaning string `json:"rootMeaning,omitempty"`
+		Components  []struct {
+			Part             string `json:"part"`
+			Meaning          string `json:"meaning"`
+			MeaningLocalized string `json:"meaningLocalized,omitempty"`
+		} `json:"components,omitempty"`
+	} `json:"origin"`
+	Evolution struct {
+		Path        string `json:"path,omitempty"`
+		Explanation string `json:"explanation,omitempty"`
+	} `json:"evolution"`
+	HistoricalContext        string `json:"historicalContext,omitempty"`
+	OriginalMeaning          string `json:"originalMeaning,omitempty"`
+	OriginalMeaningLocalized string `json:"originalMeaningLocalized,omitempty"`
+	ModernMeaning            string `json:"modernMeaning,omitempty"`
+	ModernMeaningLocalized   string `json:"modernMeaningLocalized,omitempty"`
+	Derivatives              []struct {
+		Word    string `json:"word"`
+		Meaning string `json:"meaning"`
+	} `json:"derivatives,omitempty"`
+	Synonyms []struct {
+		Word    string `json:"word"`
+		Meaning string `json:"meaning"`
+		Nuance  string `json:"nuance,omitempty"`
+	} `json:"synonyms,omitempty"`
+	Senses []struct {
+		Meaning               string `json:"meaning"`
+		English               string `json:"english"`
+		Domain                string `json:"domain,omitempty"`
+		MetaphoricalExtension string `json:"metaphoricalExtension,omitempty"`
+		Example               *struct {
+			English     string `json:"english"`
+			Translation string `json:"translation"`
+		} `json:"example,omitempty"`
+	} `json:"senses,omitempty"`
+}
+
+type batchEtymologySchema struct {
+	Results []wordEtymologySchema `json:"results"`
+}
+
+type affixOriginSchema struct {
+	Language        string `json:"language"`
+	OriginalForm    string `json:"originalForm"`
+	OriginalMeaning string `json:"originalMeaning,omitempty"`
+}
+
+type affixExampleSchema struct {
+	Word        string `json:"word"`
+	Base        string `json:"base,omitempty"`
+	Meaning     string `json:"meaning,omitempty"`
+	Explanation string `json:"explanation,omitempty"`
+}
+
+type suffixEtymologySchema struct {
+	Word       string `json:"word"`
+	Type       string `json:"type" enum:"suffix"`
+	Definition struct {
+		Brief               string `json:"brief"`
+		Detailed            string `json:"detailed,omitempty"`
+		GrammaticalFunction string `json:"grammaticalFunction,omitempty"`
+	} `json:"definition"`
+	Origin          affixOriginSchema    `json:"origin"`
+	Examples        []affixExampleSchema `json:"examples,omitempty"`
+	RelatedSuffixes []struct {
+		Suffix     string `json:"suffix"`
+		Difference string `json:"difference"`
+	} `json:"relatedSuffixes,omitempty"`
+	HistoricalContext string `json:"historicalContext,omitempty"`
+}
+
+type prefixEtymologySchema struct {
+	Word       string `json:"word"`
+	Type       string `json:"type" enum:"prefix"`
+	Definition struct {
+		Brief          string `json:"brief"`
+		Detailed       string `json:"detailed,omitempty"`
+		SemanticEffect string `json:"semanticEffect,omitempty"`
+	} `json:"definition"`
+	Origin          affixOriginSchema    `json:"origin"`
+	Examples        []affixExampleSchema `json:"examples,omitempty"`
+	RelatedPrefixes []struct {
+		Prefix     string `json:"prefix"`
+		Difference string `json:"difference"`
+	} `json:"relatedPrefixes,omitempty"`
+	HistoricalContext string `json:"historicalContext,omitempty"`
+}
@@ -0,0 +1,226 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// StreamClient is implemented by clients that can stream a JSON response as it is generated.
+// onChunk receives each piece of text in order; the full response is returned at the end.
+type StreamClient interface {
+	GenerateStream(ctx context.Context, prompt string, schema *Schema, onChunk func(text string)) (string, error)
+}
+
+// GenerateJSONStream is the streaming counterpart of GenerateJSON.
+// Clients that cannot stream produce the whole response as a single chunk.
+func GenerateJSONStream(ctx context.Context, client LLMClient, prompt string, schema *Schema, onChunk func(text string)) (string, string, error) {
+	response, err := generateStream(ctx, client, prompt, schema, onChunk)
+	if err != nil {
+		return "", "", err
+	}
+
+	jsonStr, err := ExtractJSON(response)
+	if err != nil {
+		return "", response, err
+	}
+	return jsonStr, response, nil
+}
+
+func generateStream(ctx context.Context, client LLMClient, prompt string, schema *Schema, onChunk func(text string)) (string, error) {
+	if streamClient, ok := client.(StreamClient); ok {
+		return streamClient.GenerateStream(ctx, prompt, schema, onChunk)
+	}
+
+	response, err := generateJSON(ctx, client, prompt, schema)
+	if err != nil {
+		return "", err
+	}
+	onChunk(response)
+	return response, nil
+}
+
+// GenerateStream routes like Generate, but only falls back to the next provider
+// while nothing has been streamed to the caller yet
+func (r *RouterClient) GenerateStream(ctx context.Context, prompt string, schema *Schema, onChunk func(text string)) (string, error) {
+	streamed := false
+	return r.route(ctx, func(p Provider) (string, error) {
+		return generateStream(ctx, p.Client, prompt, schema, func(text string) {
+			if !streamed {
+				streamed = true
+				RecordProvider(ctx, p.Name)
+			}
+			onChunk(text)
+		})
+	}, func() bool { return streamed })
+}
+
+// GenerateStream streams an Ollama generation (newline-delimited JSON objects)
+func (c *OllamaClient) GenerateStream(ctx context.Context, prompt string, schema *Schema, onChunk func(text string)) (string, error) {
+	var format interface{} = "json"
+	if schema != nil {
+		format = schema
+	}
+	jsonBody, err := json.Marshal(OllamaGenerateRequest{
+		Model:  c.model,
+		Prompt: prompt,
+		Stream: true,
+		Format: format,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/generate", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", newStatusError("ollama", resp, body)
+	}
+
+	var full strings.Builder
+	err = readLines(resp.Body, func(line []byte) (bool, error) {
+		var chunk struct {
+			OllamaGenerateResponse
+			Error string `json:"error"`
+		}
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return false, fmt.Errorf("failed to unmarshal stream chunk: %w", err)
+		}
+		if chunk.Error != "" {
+			return false, fmt.Errorf("ollama error: %s", chunk.Error)
+		}
+		if chunk.Response != "" {
+			full.WriteString(chunk.Response)
+			onChunk(chunk.Response)
+		}
+		if chunk.Done {
+			RecordUsage(ctx, Usage{Provider: "ollama", Model: c.model, PromptTokens: chunk.PromptEvalCount, CompletionTokens: chunk.EvalCount})
+		}
+		return chunk.Done, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return full.String(), nil
+}
+
+// GenerateStream streams a Gemini generation (server-sent events) in JSON mode
+func (c *GeminiClient) GenerateStream(ctx context.Context, prompt string, schema *Schema, onChunk func(text string)) (string, error) {
+	jsonBody, err := json.Marshal(GeminiRequest{
+		Contents: []GeminiContent{
+			{
+				Parts: []GeminiPart{
+					{Text: prompt},
+				},
+			},
+		},
+		GenerationConfig: &GeminiGenerationConfig{
+			ResponseMimeType: "application/json",
+			ResponseSchema:   schema.Gemini(),
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:streamGenerateContent?alt=sse&key=%s", c.model, c.apiKey)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", newStatusError("gemini", resp, body)
+	}
+
+	var full strings.Builder
+	var usage *GeminiUsageMetadata
+	err = readLines(resp.Body, func(line []byte) (bool, error) {
+		data, ok := bytes.CutPrefix(line, []byte("data:"))
+		if !ok {
+			return false, nil
+		}
+
+		var chunk GeminiResponse
+		if err := json.Unmarshal(bytes.TrimSpace(data), &chunk); err != nil {
+			return false, fmt.Errorf("failed to unmarshal stream chunk: %w", err)
+		}
+		if chunk.Error != nil {
+			return false, fmt.Errorf("gemini error: %s", chunk.Error.Message)
+		}
+		// Usage metadata is cumulative; the last chunk has the totals
+		if chunk.UsageMetadata != nil {
+			usage = chunk.UsageMetadata
+		}
+		if err := chunk.blocked(); err != nil {
+			return false, err
+		}
+		for _, candidate := range chunk.Candidates {
+			for _, part := range candidate.Content.Parts {
+				if part.Text != "" {
+					full.WriteString(part.Text)
+					onChunk(part.Text)
+				}
+			}
+		}
+		return false, nil
+	})
+	if usage != nil {
+		RecordUsage(ctx, usage.usage(c.model))
+	}
+	if err != nil {
+		return "", err
+	}
+	if full.Len() == 0 {
+		return "", fmt.Errorf("no response from gemini")
+	}
+	return full.String(), nil
+}
+
+// readLines calls handle with each non-empty line of r until it reports done or r ends
+func readLines(r io.Reader, handle func(line []byte) (bool, error)) error {
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			done, handleErr := handle(line)
+			if handleErr != nil {
+				return handleErr
+			}
+			if done {
+				return nil
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read stream: %w", err)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package llm
+
+import "context"
+
+// Usage is the token usage a provider reported for one generation
+type Usage struct {
+	Provider         string
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// usageRecorderKey is the context key for the usage callback
+type usageRecorderKey struct{}
+
+// WithUsageRecorder returns a context in which every client reports the usage of
+// each generation to onUsage, including repair attempts and failed fallbacks
+// that still consumed tokens
+func WithUsageRecorder(ctx context.Context, onUsage func(Usage)) context.Context {
+	return context.WithValue(ctx, usageRecorderKey{}, onUsage)
+}
+
+// RecordUsage reports usage to the recorder in ctx, if any
+func RecordUsage(ctx context.Context, usage Usage) {
+	if onUsage, ok := ctx.Value(usageRecorderKey{}).(func(Usage)); ok {
+		onUsage(usage)
+	}
+}
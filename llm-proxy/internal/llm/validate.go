@@ -0,0 +1,174 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// EtymologyKind selects which prompt shape a response is validated against
+type EtymologyKind string
+
+const (
+	EtymologyKindWord   EtymologyKind = "word"
+	EtymologyKindSuffix EtymologyKind = "suffix"
+	EtymologyKindPrefix EtymologyKind = "prefix"
+)
+
+// Violation describes a single rule from the etymology prompts that a response broke
+type Violation struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func (v Violation) String() string {
+	if v.Field == "" {
+		return v.Message
+	}
+	return fmt.Sprintf("%s: %s", v.Field, v.Message)
+}
+
+// nonStandardLanguages maps language names the prompts forbid to the required form
+var nonStandardLanguages = map[string]string{
+	"ancient greek":   "Greek",
+	"classical greek": "Greek",
+	"hellenic":        "Greek",
+	"classical latin": "Latin",
+	"vulgar latin":    "Latin",
+	"anglo-saxon":     "Old English",
+	"anglo saxon":     "Old English",
+}
+
+// nonLatinScriptLanguages are target languages whose "brief" must not be English-only
+var nonLatinScriptLanguages = map[string]bool{
+	"korean":   true,
+	"japanese": true,
+	"chinese":  true,
+	"russian":  true,
+	"arabic":   true,
+	"hindi":    true,
+	"thai":     true,
+	"greek":    true,
+	"hebrew":   true,
+}
+
+// componentPattern matches English affix forms such as "pre-", "-tion", "script-" or "view"
+var componentPattern = regexp.MustCompile(`^-?[a-z]+-?$`)
+
+type etymologyResponse struct {
+	Word       string `json:"word"`
+	Type       string `json:"type"`
+	Definition struct {
+		Brief string `json:"brief"`
+	} `json:"definition"`
+	Origin struct {
+		Language     string `json:"language"`
+		Root         string `json:"root"`
+		OriginalForm string `json:"originalForm"`
+		Components   []struct {
+			Part string `json:"part"`
+		} `json:"components"`
+	} `json:"origin"`
+	Derivatives []struct {
+		Word string `json:"word"`
+	} `json:"derivatives"`
+}
+
+// ValidateEtymology checks an extracted etymology JSON object against the rules
+// stated in EtymologyPrompt, SuffixEtymologyPrompt and PrefixEtymologyPrompt.
+// It returns every violation found; an empty slice means the response is usable.
+func ValidateEtymology(jsonStr string, kind EtymologyKind, targetLang string) []Violation {
+	var resp etymologyResponse
+	if err := json.Unmarshal([]byte(jsonStr), &resp); err != nil {
+		return []Violation{{Rule: "schema", Message: fmt.Sprintf("response does not match the JSON schema: %v", err)}}
+	}
+
+	var v []Violation
+	add := func(field, rule, format string, args ...interface{}) {
+		v = append(v, Violation{Field: field, Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// Shared rules
+	if strings.TrimSpace(resp.Word) == "" {
+		add("word", "required", "must not be empty")
+	} else if resp.Word != strings.ToLower(resp.Word) {
+		add("word", "lowercase", "must be lowercase (got %q)", resp.Word)
+	}
+
+	brief := strings.TrimSpace(resp.Definition.Brief)
+	if brief == "" {
+		add("definition.brief", "required", "must not be empty")
+	} else if nonLatinScriptLanguages[strings.ToLower(targetLang)] && isASCIIOnly(brief) {
+		add("definition.brief", "localized", "must be written in %s, not English (got %q)", targetLang, brief)
+	}
+
+	language := strings.TrimSpace(resp.Origin.Language)
+	if language == "" {
+		add("origin.language", "required", "must not be empty")
+	} else if standard, ok := nonStandardLanguages[strings.ToLower(language)]; ok {
+		add("origin.language", "language_name", "use %q instead of %q", standard, language)
+	}
+
+	switch kind {
+	case EtymologyKindSuffix, EtymologyKindPrefix:
+		if resp.Type != string(kind) {
+			add("type", "required", "must be %q", kind)
+		}
+		if strings.TrimSpace(resp.Origin.OriginalForm) == "" {
+			add("origin.originalForm", "required", "must not be empty")
+		} else if !isASCIIOnly(resp.Origin.OriginalForm) {
+			add("origin.originalForm", "ascii", "must use ASCII spelling without diacritics or non-Latin script (got %q)", resp.Origin.OriginalForm)
+		}
+	default:
+		root := strings.TrimSpace(resp.Origin.Root)
+		if root == "" {
+			add("origin.root", "required", "must not be empty")
+		} else {
+			if !isASCIIOnly(root) {
+				add("origin.root", "ascii", "must use ASCII spelling without diacritics or non-Latin script (got %q)", root)
+			}
+			if root != strings.ToLower(root) {
+				add("origin.root", "lowercase", "must be lowercase (got %q)", root)
+			}
+		}
+
+		for i, comp := range resp.Origin.Components {
+			field := fmt.Sprintf("origin.components[%d].part", i)
+			part := strings.TrimSpace(comp.Part)
+			switch {
+			case part == "":
+				add(field, "required", "must not be empty")
+			case !isASCIIOnly(part):
+				add(field, "ascii", "must be an English affix form, not original script (got %q)", part)
+			case part != strings.ToLower(part):
+				add(field, "lowercase", "must be lowercase (got %q)", part)
+			case !componentPattern.MatchString(part):
+				add(field, "component_format", "must look like \"prefix-\", \"-suffix\" or \"root\" (got %q)", part)
+			}
+		}
+
+		for i, deriv := range resp.Derivatives {
+			field := fmt.Sprintf("derivatives[%d].word", i)
+			if strings.TrimSpace(deriv.Word) == "" {
+				add(field, "required", "must not be empty")
+			} else if deriv.Word != strings.ToLower(deriv.Word) {
+				add(field, "lowercase", "must be lowercase (got %q)", deriv.Word)
+			}
+		}
+	}
+
+	return v
+}
+
+// isASCIIOnly reports whether every rune in s is ASCII
+func isASCIIOnly(s string) bool {
+	for _, r := range s {
+		if r > unicode.MaxASCII {
+			return false
+		}
+	}
+	return true
+}
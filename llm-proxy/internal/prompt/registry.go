@@ -0,0 +1,198 @@
+package prompt
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// Prompt IDs used by the handlers
+const (
+	Etymology       = "etymology"
+	EtymologySuffix = "etymology-suffix"
+	EtymologyPrefix = "etymology-prefix"
+	EtymologyRepair = "etymology-repair"
+	EtymologyBatch  = "etymology-batch"
+	Derivatives     = "derivatives"
+	Synonyms        = "synonyms"
+)
+
+//go:embed templates/*.tmpl
+var embedded embed.FS
+
+// fileNamePattern matches "<id>.v<version>.tmpl", e.g. "etymology.v2.tmpl"
+var fileNamePattern = regexp.MustCompile(`^([a-z0-9-]+)\.v([0-9]+)\.tmpl$`)
+
+// descriptionPattern matches a leading {{/* description */}} comment
+var descriptionPattern = regexp.MustCompile(`^\{\{-?\s*/\*\s*(.*?)\s*\*/\s*-?\}\}`)
+
+// WordData is the template data for the etymology, derivatives and synonyms prompts
+type WordData struct {
+	Word     string
+	Language string
+}
+
+// RepairData is the template data for the etymology-repair prompt
+type RepairData struct {
+	Violations string
+	Language   string
+	Prompt     string
+	Response   string
+}
+
+// BatchData is the template data for the etymology-batch prompt.
+// Prompt is the etymology prompt rendered for the first word.
+type BatchData struct {
+	Words    []string
+	Language string
+	Prompt   string
+}
+
+// Template is one version of a named prompt
+type Template struct {
+	ID          string
+	Version     int
+	Description string
+	tmpl        *template.Template
+}
+
+// Render executes the template with data
+func (t *Template) Render(data interface{}) (string, error) {
+	var out strings.Builder
+	if err := t.tmpl.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("render prompt %s v%d: %w", t.ID, t.Version, err)
+	}
+	return strings.TrimSpace(out.String()), nil
+}
+
+// Info describes a prompt version for GET /api/prompts
+type Info struct {
+	ID          string `json:"id"`
+	Version     int    `json:"version"`
+	Description string `json:"description,omitempty"`
+	Active      bool   `json:"active"`
+}
+
+// Registry holds every loaded prompt version. The highest version of each ID is active.
+type Registry struct {
+	versions map[string][]*Template // sorted by version, ascending
+}
+
+// Load reads the embedded templates, then any templates in dir (if set).
+// Files in dir add new versions or replace embedded ones with the same ID and version.
+func Load(dir string) (*Registry, error) {
+	r := &Registry{versions: make(map[string][]*Template)}
+
+	sub, err := fs.Sub(embedded, "templates")
+	if err != nil {
+		return nil, err
+	}
+	if err := r.load(sub); err != nil {
+		return nil, err
+	}
+	if dir != "" {
+		if err := r.load(os.DirFS(dir)); err != nil {
+			return nil, fmt.Errorf("load prompts from %s: %w", dir, err)
+		}
+	}
+
+	for _, id := range []string{Etymology, EtymologySuffix, EtymologyPrefix, EtymologyRepair, EtymologyBatch, Derivatives, Synonyms} {
+		if _, err := r.Get(id); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func (r *Registry) load(fsys fs.FS) error {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		match := fileNamePattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, _ := strconv.Atoi(match[2])
+
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return err
+		}
+		tmpl, err := template.New(path.Base(entry.Name())).Option("missingkey=error").Parse(string(data))
+		if err != nil {
+			return fmt.Errorf("parse %s: %w", entry.Name(), err)
+		}
+
+		t := &Template{ID: match[1], Version: version, tmpl: tmpl}
+		if desc := descriptionPattern.FindSubmatch(data); desc != nil {
+			t.Description = string(desc[1])
+		}
+		r.add(t)
+	}
+	return nil
+}
+
+func (r *Registry) add(t *Template) {
+	versions := r.versions[t.ID]
+	for i, existing := range versions {
+		if existing.Version == t.Version {
+			versions[i] = t
+			return
+		}
+	}
+	versions = append(versions, t)
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Version < versions[j].Version })
+	r.versions[t.ID] = versions
+}
+
+// Get returns the active (highest) version of a prompt
+func (r *Registry) Get(id string) (*Template, error) {
+	versions := r.versions[id]
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("prompt %q not found", id)
+	}
+	return versions[len(versions)-1], nil
+}
+
+// Version returns a specific version of a prompt
+func (r *Registry) Version(id string, version int) (*Template, error) {
+	for _, t := range r.versions[id] {
+		if t.Version == version {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("prompt %q version %d not found", id, version)
+}
+
+// List returns every loaded prompt version, ordered by ID and version
+func (r *Registry) List() []Info {
+	ids := make([]string, 0, len(r.versions))
+	for id := range r.versions {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var list []Info
+	for _, id := range ids {
+		versions := r.versions[id]
+		for i, t := range versions {
+			list = append(list, Info{
+				ID:          t.ID,
+				Version:     t.Version,
+				Description: t.Description,
+				Active:      i == len(versions)-1,
+			})
+		}
+	}
+	return list
+}
@@ -0,0 +1,95 @@
+package usage
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// fieldSep separates the endpoint, provider, model and counter in a hash field
+const fieldSep = "|"
+
+// RedisStore keeps daily totals in Redis, so they survive restarts and are shared
+// between llm-proxy replicas. Each day is a hash "usage:{date}" with one field per
+// endpoint, provider, model and counter ("/api/etymology|gemini|gemini-2.0-flash|cost").
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+func NewRedisStore(redisURL string) (*RedisStore, error) {
+	opt, err := redis.ParseURL(redisURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse redis URL: %w", err)
+	}
+
+	client := redis.NewClient(opt)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+
+	return &RedisStore{client: client, prefix: "usage:"}, nil
+}
+
+// Add increments date's totals by delta; the day expires after retainDays
+func (s *RedisStore) Add(ctx context.Context, date string, delta Totals) error {
+	key := s.prefix + date
+	field := strings.Join([]string{delta.Endpoint, delta.Provider, delta.Model}, fieldSep) + fieldSep
+
+	pipe := s.client.TxPipeline()
+	pipe.HIncrBy(ctx, key, field+"calls", delta.Calls)
+	pipe.HIncrBy(ctx, key, field+"prompt", delta.PromptTokens)
+	pipe.HIncrBy(ctx, key, field+"completion", delta.CompletionTokens)
+	pipe.HIncrByFloat(ctx, key, field+"cost", delta.CostUSD)
+	pipe.Expire(ctx, key, (retainDays+1)*24*time.Hour)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// Load returns date's totals per endpoint, provider and model
+func (s *RedisStore) Load(ctx context.Context, date string) ([]Totals, error) {
+	fields, err := s.client.HGetAll(ctx, s.prefix+date).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	byKey := make(map[totalsKey]*Totals)
+	for field, value := range fields {
+		parts := strings.Split(field, fieldSep)
+		if len(parts) != 4 {
+			continue
+		}
+		key := totalsKey{parts[0], parts[1], parts[2]}
+		totals, ok := byKey[key]
+		if !ok {
+			totals = &Totals{Endpoint: parts[0], Provider: parts[1], Model: parts[2]}
+			byKey[key] = totals
+		}
+		switch parts[3] {
+		case "calls":
+			totals.Calls, _ = strconv.ParseInt(value, 10, 64)
+		case "prompt":
+			totals.PromptTokens, _ = strconv.ParseInt(value, 10, 64)
+		case "completion":
+			totals.CompletionTokens, _ = strconv.ParseInt(value, 10, 64)
+		case "cost":
+			totals.CostUSD, _ = strconv.ParseFloat(value, 64)
+		}
+	}
+
+	list := make([]Totals, 0, len(byKey))
+	for _, totals := range byKey {
+		list = append(list, *totals)
+	}
+	return list, nil
+}
+
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
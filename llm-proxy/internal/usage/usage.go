@@ -0,0 +1,292 @@
+package usage
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/epikoding/etymograph/llm-proxy/internal/llm"
+)
+
+// dateLayout is the key format for a UTC day
+const dateLayout = "2006-01-02"
+
+// retainDays is how many days of totals are kept for GET /api/usage
+const retainDays = 31
+
+// storeTimeout bounds each Store call, so a slow store cannot hold up requests
+const storeTimeout = time.Second
+
+// budgetCheckInterval is how long Exceeded reuses its answer before loading today's
+// totals again, so budget checks do not read the store on every request
+const budgetCheckInterval = 5 * time.Second
+
+// Price is a model's price in USD per 1M tokens
+type Price struct {
+	Input  float64
+	Output float64
+}
+
+// DefaultPrices are list prices for the default models of each provider.
+// Keys are matched against the model name by longest prefix, then against the provider name.
+var DefaultPrices = map[string]Price{
+	"ollama":            {0, 0},
+	"gemini-2.0-flash":  {0.10, 0.40},
+	"gemini-1.5-flash":  {0.075, 0.30},
+	"gemini-1.5-pro":    {1.25, 5.00},
+	"gpt-4o-mini":       {0.15, 0.60},
+	"gpt-4o":            {2.50, 10.00},
+	"claude-3-5-haiku":  {0.80, 4.00},
+	"claude-3-5-sonnet": {3.00, 15.00},
+}
+
+// ParsePrices parses "model=input/output,..." (USD per 1M tokens) on top of DefaultPrices
+func ParsePrices(s string) (map[string]Price, error) {
+	prices := make(map[string]Price, len(DefaultPrices))
+	for k, v := range DefaultPrices {
+		prices[k] = v
+	}
+
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		model, rates, ok := strings.Cut(item, "=")
+		input, output, ok2 := strings.Cut(rates, "/")
+		if !ok || !ok2 {
+			return nil, fmt.Errorf("invalid price %q (want model=input/output)", item)
+		}
+		in, err := strconv.ParseFloat(strings.TrimSpace(input), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid input price in %q: %w", item, err)
+		}
+		out, err := strconv.ParseFloat(strings.TrimSpace(output), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid output price in %q: %w", item, err)
+		}
+		prices[strings.TrimSpace(model)] = Price{Input: in, Output: out}
+	}
+	return prices, nil
+}
+
+// Budget limits usage per UTC day; zero disables a limit
+type Budget struct {
+	DailyUSD    float64 `json:"dailyUsd"`
+	DailyTokens int64   `json:"dailyTokens"`
+}
+
+// Totals are the accumulated usage of one endpoint/provider/model on one day
+type Totals struct {
+	Endpoint         string  `json:"endpoint"`
+	Provider         string  `json:"provider"`
+	Model            string  `json:"model"`
+	Calls            int64   `json:"calls"`
+	PromptTokens     int64   `json:"promptTokens"`
+	CompletionTokens int64   `json:"completionTokens"`
+	CostUSD          float64 `json:"costUsd"`
+}
+
+type totalsKey struct {
+	endpoint, provider, model string
+}
+
+// Summary is the response of GET /api/usage for one day
+type Summary struct {
+	Date             string   `json:"date"`
+	Calls            int64    `json:"calls"`
+	PromptTokens     int64    `json:"promptTokens"`
+	CompletionTokens int64    `json:"completionTokens"`
+	TotalTokens      int64    `json:"totalTokens"`
+	CostUSD          float64  `json:"costUsd"`
+	Budget           Budget   `json:"budget"`
+	BudgetExceeded   bool     `json:"budgetExceeded"`
+	Breakdown        []Totals `json:"breakdown"`
+}
+
+// Store persists daily totals beyond the process, e.g. RedisStore
+type Store interface {
+	// Add increments date's totals for delta's endpoint, provider and model
+	Add(ctx context.Context, date string, delta Totals) error
+	// Load returns all of date's totals
+	Load(ctx context.Context, date string) ([]Totals, error)
+}
+
+// Tracker accumulates token usage and estimated cost per UTC day.
+// Without a store, totals are process-local and reset on restart. With one, summaries
+// and budgets use the store's totals, falling back to this process's own if it fails.
+type Tracker struct {
+	mu     sync.Mutex
+	prices map[string]Price
+	budget Budget
+	store  Store
+	days   map[string]map[totalsKey]*Totals
+
+	// Exceeded's last answer, for budgetCheckInterval on checkedDate
+	checkMu     sync.Mutex
+	checkedDate string
+	checkedAt   time.Time
+	exceeded    bool
+}
+
+// NewTracker creates a tracker; store may be nil
+func NewTracker(prices map[string]Price, budget Budget, store Store) *Tracker {
+	return &Tracker{
+		prices: prices,
+		budget: budget,
+		store:  store,
+		days:   make(map[string]map[totalsKey]*Totals),
+	}
+}
+
+// Record adds one generation's usage to today's totals and returns its estimated cost
+func (t *Tracker) Record(endpoint string, u llm.Usage) float64 {
+	price := t.price(u.Provider, u.Model)
+	cost := (float64(u.PromptTokens)*price.Input + float64(u.CompletionTokens)*price.Output) / 1e6
+
+	today := time.Now().UTC().Format(dateLayout)
+	delta := Totals{
+		Endpoint:         endpoint,
+		Provider:         u.Provider,
+		Model:            u.Model,
+		Calls:            1,
+		PromptTokens:     int64(u.PromptTokens),
+		CompletionTokens: int64(u.CompletionTokens),
+		CostUSD:          cost,
+	}
+	t.add(today, delta)
+
+	if t.store != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+		defer cancel()
+		if err := t.store.Add(ctx, today, delta); err != nil {
+			log.Printf("Failed to store usage: %v", err)
+		}
+	}
+	return cost
+}
+
+// add adds delta to this process's totals for today
+func (t *Tracker) add(today string, delta Totals) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	day, ok := t.days[today]
+	if !ok {
+		day = make(map[totalsKey]*Totals)
+		t.days[today] = day
+		t.prune(today)
+	}
+
+	key := totalsKey{delta.Endpoint, delta.Provider, delta.Model}
+	totals, ok := day[key]
+	if !ok {
+		totals = &Totals{Endpoint: delta.Endpoint, Provider: delta.Provider, Model: delta.Model}
+		day[key] = totals
+	}
+	totals.Calls += delta.Calls
+	totals.PromptTokens += delta.PromptTokens
+	totals.CompletionTokens += delta.CompletionTokens
+	totals.CostUSD += delta.CostUSD
+}
+
+// price finds the longest model prefix in the price table, then the provider.
+// Unknown models are counted as free.
+func (t *Tracker) price(provider, model string) Price {
+	best, found := "", false
+	for name := range t.prices {
+		if strings.HasPrefix(model, name) && len(name) > len(best) {
+			best, found = name, true
+		}
+	}
+	if found {
+		return t.prices[best]
+	}
+	return t.prices[provider]
+}
+
+// prune drops days older than retainDays; callers hold t.mu
+func (t *Tracker) prune(today string) {
+	now, _ := time.Parse(dateLayout, today)
+	cutoff := now.AddDate(0, 0, -retainDays).Format(dateLayout)
+	for date := range t.days {
+		if date < cutoff {
+			delete(t.days, date)
+		}
+	}
+}
+
+// Summary returns the totals for date ("YYYY-MM-DD", UTC)
+func (t *Tracker) Summary(date string) Summary {
+	if t.store != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+		defer cancel()
+		totals, err := t.store.Load(ctx, date)
+		if err == nil {
+			return t.summary(date, totals)
+		}
+		log.Printf("Failed to load usage, using this replica's totals: %v", err)
+	}
+
+	t.mu.Lock()
+	totals := make([]Totals, 0, len(t.days[date]))
+	for _, day := range t.days[date] {
+		totals = append(totals, *day)
+	}
+	t.mu.Unlock()
+	return t.summary(date, totals)
+}
+
+func (t *Tracker) summary(date string, breakdown []Totals) Summary {
+	s := Summary{Date: date, Budget: t.budget, Breakdown: []Totals{}}
+	for _, totals := range breakdown {
+		s.Calls += totals.Calls
+		s.PromptTokens += totals.PromptTokens
+		s.CompletionTokens += totals.CompletionTokens
+		s.CostUSD += totals.CostUSD
+		s.Breakdown = append(s.Breakdown, totals)
+	}
+	s.TotalTokens = s.PromptTokens + s.CompletionTokens
+	s.BudgetExceeded = (t.budget.DailyUSD > 0 && s.CostUSD >= t.budget.DailyUSD) ||
+		(t.budget.DailyTokens > 0 && s.TotalTokens >= t.budget.DailyTokens)
+
+	sort.Slice(s.Breakdown, func(i, j int) bool { return s.Breakdown[i].CostUSD > s.Breakdown[j].CostUSD })
+	return s
+}
+
+// Today returns today's summary
+func (t *Tracker) Today() Summary {
+	return t.Summary(time.Now().UTC().Format(dateLayout))
+}
+
+// Exceeded reports whether today's usage has reached a daily budget limit.
+// The answer may be up to budgetCheckInterval old; without limits it is always false.
+func (t *Tracker) Exceeded() bool {
+	if t.budget.DailyUSD <= 0 && t.budget.DailyTokens <= 0 {
+		return false
+	}
+
+	now := time.Now()
+	today := now.UTC().Format(dateLayout)
+
+	// Held while loading, so concurrent checks share one load
+	t.checkMu.Lock()
+	defer t.checkMu.Unlock()
+	if today == t.checkedDate && now.Sub(t.checkedAt) < budgetCheckInterval {
+		return t.exceeded
+	}
+	t.exceeded = t.Summary(today).BudgetExceeded
+	t.checkedDate, t.checkedAt = today, now
+	return t.exceeded
+}
+
+// ResetIn returns the time until the daily budget resets (UTC midnight)
+func ResetIn() time.Duration {
+	now := time.Now().UTC()
+	return now.Truncate(24 * time.Hour).Add(24 * time.Hour).Sub(now)
+}
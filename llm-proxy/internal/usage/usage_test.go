@@ -0,0 +1,66 @@
+package usage
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/epikoding/etymograph/llm-proxy/internal/llm"
+)
+
+// countingStore is an in-memory Store that counts loads
+type countingStore struct {
+	mu     sync.Mutex
+	totals []Totals
+	loads  int
+}
+
+func (s *countingStore) Add(ctx context.Context, date string, delta Totals) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.totals = append(s.totals, delta)
+	return nil
+}
+
+func (s *countingStore) Load(ctx context.Context, date string) ([]Totals, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loads++
+	return append([]Totals(nil), s.totals...), nil
+}
+
+func TestExceededWithoutBudgetSkipsStore(t *testing.T) {
+	store := &countingStore{}
+	tracker := NewTracker(DefaultPrices, Budget{}, store)
+	tracker.Record("/api/etymology", llm.Usage{Provider: "gemini", Model: "gemini-2.0-flash", PromptTokens: 1e6})
+
+	for i := 0; i < 10; i++ {
+		if tracker.Exceeded() {
+			t.Fatal("Exceeded without a budget")
+		}
+	}
+	if store.loads != 0 {
+		t.Errorf("store loaded %d times without a budget", store.loads)
+	}
+}
+
+func TestExceededReusesTodaysTotals(t *testing.T) {
+	store := &countingStore{}
+	tracker := NewTracker(DefaultPrices, Budget{DailyTokens: 100}, store)
+
+	for i := 0; i < 10; i++ {
+		if tracker.Exceeded() {
+			t.Fatal("Exceeded before any usage")
+		}
+	}
+	if store.loads != 1 {
+		t.Errorf("store loaded %d times for 10 checks, want 1", store.loads)
+	}
+
+	// A new total is picked up once the cached answer expires
+	tracker.Record("/api/etymology", llm.Usage{Provider: "gemini", Model: "gemini-2.0-flash", PromptTokens: 80, CompletionTokens: 40})
+	tracker.checkedAt = tracker.checkedAt.Add(-budgetCheckInterval)
+	if !tracker.Exceeded() {
+		t.Error("not Exceeded after using 120 of 100 daily tokens")
+	}
+}
@@ -0,0 +1,105 @@
+// Package client is a pooled gRPC client for the rate-limiter service.
+// It only depends on public packages of this module so other services
+// (e.g. api-go) can import it.
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	ratelimiterv1 "github.com/epikoding/etymograph/rate-limiter/proto/ratelimiter/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+)
+
+// Options configures New. Zero values use the defaults.
+type Options struct {
+	// PoolSize is the number of connections requests are spread over (default 4)
+	PoolSize int
+	// Timeout is the deadline applied to calls whose context has none (default 200ms)
+	Timeout time.Duration
+	// DialOptions are appended to the defaults (insecure transport, keepalive)
+	DialOptions []grpc.DialOption
+}
+
+// Client round-robins calls over a fixed pool of connections
+type Client struct {
+	conns   []*grpc.ClientConn
+	clients []ratelimiterv1.RateLimiterClient
+	next    atomic.Uint64
+	timeout time.Duration
+}
+
+// New dials target (e.g. "rate-limiter:9090"). Connections are established lazily,
+// so New does not fail when the rate-limiter is not up yet.
+func New(target string, opts Options) (*Client, error) {
+	if opts.PoolSize <= 0 {
+		opts.PoolSize = 4
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 200 * time.Millisecond
+	}
+
+	dialOpts := append([]grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                30 * time.Second,
+			Timeout:             5 * time.Second,
+			PermitWithoutStream: true,
+		}),
+	}, opts.DialOptions...)
+
+	c := &Client{timeout: opts.Timeout}
+	for i := 0; i < opts.PoolSize; i++ {
+		conn, err := grpc.Dial(target, dialOpts...)
+		if err != nil {
+			c.Close()
+			return nil, fmt.Errorf("failed to dial rate limiter: %w", err)
+		}
+		c.conns = append(c.conns, conn)
+		c.clients = append(c.clients, ratelimiterv1.NewRateLimiterClient(conn))
+	}
+
+	return c, nil
+}
+
+// Check consumes cost requests for clientID/action (cost 0 counts as 1).
+// A denied request is not an error; check Allowed on the response.
+func (c *Client) Check(ctx context.Context, req *ratelimiterv1.CheckRequest) (*ratelimiterv1.CheckResponse, error) {
+	ctx, cancel := c.withDeadline(ctx)
+	defer cancel()
+	return c.pick().Check(ctx, req)
+}
+
+// CheckBatch evaluates several checks atomically (all-or-nothing)
+func (c *Client) CheckBatch(ctx context.Context, req *ratelimiterv1.CheckBatchRequest) (*ratelimiterv1.CheckBatchResponse, error) {
+	ctx, cancel := c.withDeadline(ctx)
+	defer cancel()
+	return c.pick().CheckBatch(ctx, req)
+}
+
+// Close closes every pooled connection
+func (c *Client) Close() error {
+	var errs []error
+	for _, conn := range c.conns {
+		if err := conn.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c *Client) pick() ratelimiterv1.RateLimiterClient {
+	return c.clients[c.next.Add(1)%uint64(len(c.clients))]
+}
+
+func (c *Client) withDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.timeout)
+}
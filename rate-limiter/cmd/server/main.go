@@ -1,14 +1,24 @@
 package main
 
 import (
+	"context"
 	"log"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/epikoding/etymograph/rate-limiter/internal/config"
+	"github.com/epikoding/etymograph/rate-limiter/internal/grpcserver"
 	"github.com/epikoding/etymograph/rate-limiter/internal/handler"
 	"github.com/epikoding/etymograph/rate-limiter/internal/limiter"
 	"github.com/epikoding/etymograph/rate-limiter/internal/storage"
+	ratelimiterv1 "github.com/epikoding/etymograph/rate-limiter/proto/ratelimiter/v1"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
 )
 
 func main() {
@@ -17,15 +27,53 @@ func main() {
 
 	cfg := config.Load()
 
-	// Initialize Redis storage
+	// Initialize Redis storage with an in-memory fallback behind a circuit breaker
 	redisStorage, err := storage.NewRedisStorage(cfg.RedisURL)
 	if err != nil {
-		log.Fatalf("Failed to connect to Redis: %v", err)
+		log.Fatalf("Failed to initialize Redis: %v", err)
+	}
+	store := storage.NewFailoverStorage(redisStorage, cfg.BreakerFailures, cfg.BreakerCooldown, cfg.RedisTimeout)
+	defer store.Close()
+
+	pingCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	err = redisStorage.Ping(pingCtx)
+	cancel()
+	if err != nil {
+		if cfg.RedisRequired {
+			log.Fatalf("Failed to connect to Redis: %v", err)
+		}
+		store.Degrade(err)
+	}
+
+	// Load rate limit policy and reload it on SIGHUP or file change
+	policies, err := limiter.NewPolicyStore(cfg.PolicyFile)
+	if err != nil {
+		log.Fatalf("Failed to load policy: %v", err)
+	}
+	if cfg.PolicyFile != "" {
+		reload := make(chan os.Signal, 1)
+		signal.Notify(reload, syscall.SIGHUP)
+		done := make(chan struct{})
+		defer close(done)
+		go policies.Watch(reload, cfg.PolicyReloadInterval, done)
 	}
-	defer redisStorage.Close()
 
 	// Initialize limiter
-	rateLimiter := limiter.NewLimiter(redisStorage)
+	rateLimiter := limiter.NewLimiter(store, policies)
+
+	// gRPC server (same checks as POST /check and /check/batch)
+	lis, err := net.Listen("tcp", ":"+cfg.GRPCPort)
+	if err != nil {
+		log.Fatalf("Failed to listen on gRPC port %s: %v", cfg.GRPCPort, err)
+	}
+	grpcServer := grpc.NewServer()
+	ratelimiterv1.RegisterRateLimiterServer(grpcServer, grpcserver.NewServer(rateLimiter))
+	go func() {
+		if err := grpcServer.Serve(lis); err != nil {
+			log.Printf("gRPC server stopped: %v", err)
+		}
+	}()
+	defer grpcServer.GracefulStop()
 
 	// Initialize handler
 	checkHandler := handler.NewCheckHandler(rateLimiter)
@@ -33,17 +81,25 @@ func main() {
 	// Setup router
 	r := gin.Default()
 
-	// Health check
+	// Health check (degraded means checks are served by the in-memory fallback)
 	r.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "ok"})
+		c.JSON(200, gin.H{"status": "ok", "degraded": store.Degraded()})
 	})
 
+	// Prometheus metrics endpoint
+	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
 	// API routes
 	r.POST("/check", checkHandler.Check)
+	r.POST("/check/batch", checkHandler.CheckBatch)
+	r.POST("/reset", handler.AdminTokenMiddleware(cfg.AdminToken), checkHandler.Reset)
 	r.GET("/limits", checkHandler.GetLimits)
 
-	log.Printf("Rate Limiter starting on port %s", cfg.Port)
+	log.Printf("Rate Limiter starting on port %s (gRPC %s)", cfg.Port, cfg.GRPCPort)
 	log.Printf("Redis URL: %s", cfg.RedisURL)
+	if cfg.PolicyFile != "" {
+		log.Printf("Policy file: %s", cfg.PolicyFile)
+	}
 
 	if err := r.Run(":" + cfg.Port); err != nil {
 		log.Fatal(err)
@@ -2,17 +2,37 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"time"
 )
 
 type Config struct {
-	Port     string
-	RedisURL string
+	Port                 string
+	GRPCPort             string
+	RedisURL             string
+	PolicyFile           string
+	PolicyReloadInterval time.Duration
+	AdminToken           string
+	// RedisRequired makes startup fail when Redis is unreachable instead of starting degraded
+	RedisRequired   bool
+	BreakerFailures int
+	BreakerCooldown time.Duration
+	// RedisTimeout bounds each rate limit evaluation on Redis before it counts as failed
+	RedisTimeout time.Duration
 }
 
 func Load() *Config {
 	return &Config{
-		Port:     getEnv("PORT", "8080"),
-		RedisURL: getEnv("REDIS_URL", "redis://localhost:6379"),
+		Port:                 getEnv("PORT", "8080"),
+		GRPCPort:             getEnv("GRPC_PORT", "9090"),
+		RedisURL:             getEnv("REDIS_URL", "redis://localhost:6379"),
+		PolicyFile:           getEnv("POLICY_FILE", ""),
+		PolicyReloadInterval: getEnvDuration("POLICY_RELOAD_INTERVAL", 10*time.Second),
+		AdminToken:           getEnv("ADMIN_TOKEN", ""),
+		RedisRequired:        getEnv("REDIS_REQUIRED", "false") == "true",
+		BreakerFailures:      getEnvInt("BREAKER_FAILURES", 3),
+		BreakerCooldown:      getEnvDuration("BREAKER_COOLDOWN", 10*time.Second),
+		RedisTimeout:         getEnvDuration("REDIS_TIMEOUT", 200*time.Millisecond),
 	}
 }
 
@@ -22,3 +42,21 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
@@ -0,0 +1,96 @@
+package grpcserver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/epikoding/etymograph/rate-limiter/internal/limiter"
+	ratelimiterv1 "github.com/epikoding/etymograph/rate-limiter/proto/ratelimiter/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxBatchSize mirrors the HTTP handler's cap on checks per batch
+const maxBatchSize = 50
+
+// Server implements ratelimiterv1.RateLimiterServer on top of limiter.Limiter.
+// Unlike HTTP, a denied check is not an error: it returns Allowed=false.
+type Server struct {
+	ratelimiterv1.UnimplementedRateLimiterServer
+	limiter *limiter.Limiter
+}
+
+func NewServer(l *limiter.Limiter) *Server {
+	return &Server{limiter: l}
+}
+
+func (s *Server) Check(ctx context.Context, req *ratelimiterv1.CheckRequest) (*ratelimiterv1.CheckResponse, error) {
+	if req.GetClientId() == "" || req.GetAction() == "" {
+		return nil, status.Error(codes.InvalidArgument, "client_id and action are required")
+	}
+	if req.GetCost() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "cost must not be negative")
+	}
+
+	item := limiter.CheckItem{
+		ClientID: req.GetClientId(),
+		Tier:     req.GetTier(),
+		Action:   req.GetAction(),
+		Cost:     req.GetCost(),
+	}
+	_, results, err := s.limiter.CheckBatch(ctx, []limiter.CheckItem{item}, req.GetPeek())
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return toResponse(results[0]), nil
+}
+
+func (s *Server) CheckBatch(ctx context.Context, req *ratelimiterv1.CheckBatchRequest) (*ratelimiterv1.CheckBatchResponse, error) {
+	checks := req.GetChecks()
+	if len(checks) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "checks must not be empty")
+	}
+	if len(checks) > maxBatchSize {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("at most %d checks per batch", maxBatchSize))
+	}
+
+	items := make([]limiter.CheckItem, len(checks))
+	for i, check := range checks {
+		if check.GetClientId() == "" || check.GetAction() == "" {
+			return nil, status.Errorf(codes.InvalidArgument, "checks[%d]: client_id and action are required", i)
+		}
+		if check.GetCost() < 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "checks[%d]: cost must not be negative", i)
+		}
+		items[i] = limiter.CheckItem{
+			ClientID: check.GetClientId(),
+			Tier:     check.GetTier(),
+			Action:   check.GetAction(),
+			Cost:     check.GetCost(),
+		}
+	}
+
+	allowed, results, err := s.limiter.CheckBatch(ctx, items, req.GetPeek())
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	resp := &ratelimiterv1.CheckBatchResponse{
+		Allowed: allowed,
+		Results: make([]*ratelimiterv1.CheckResponse, len(results)),
+	}
+	for i, result := range results {
+		resp.Results[i] = toResponse(result)
+	}
+	return resp, nil
+}
+
+func toResponse(r *limiter.CheckResult) *ratelimiterv1.CheckResponse {
+	return &ratelimiterv1.CheckResponse{
+		Allowed:   r.Allowed,
+		Remaining: r.Remaining,
+		ResetAt:   r.ResetAt,
+		Limit:     r.Limit,
+	}
+}
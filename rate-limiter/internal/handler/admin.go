@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminTokenMiddleware requires "Authorization: Bearer <token>" matching the configured
+// admin token. Admin endpoints are disabled when no token is configured.
+func AdminTokenMiddleware(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "admin endpoints are disabled (ADMIN_TOKEN not set)"})
+			c.Abort()
+			return
+		}
+
+		parts := strings.SplitN(c.GetHeader("Authorization"), " ", 2)
+		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" ||
+			subtle.ConstantTimeCompare([]byte(parts[1]), []byte(token)) != 1 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid admin token"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/epikoding/etymograph/rate-limiter/internal/limiter"
@@ -15,11 +16,39 @@ func NewCheckHandler(l *limiter.Limiter) *CheckHandler {
 	return &CheckHandler{limiter: l}
 }
 
+// maxBatchSize caps the number of checks in one batch request
+const maxBatchSize = 50
+
 type CheckRequest struct {
 	ClientID string `json:"client_id" binding:"required"`
 	Action   string `json:"action" binding:"required"`
+	Tier     string `json:"tier"`
+	// Cost is how many requests this check consumes (default 1)
+	Cost int64 `json:"cost" binding:"gte=0"`
+	// Peek reports the decision without consuming anything
+	Peek bool `json:"peek"`
+}
+
+type BatchCheckRequest struct {
+	Checks []BatchCheckItem `json:"checks" binding:"required,min=1,dive"`
+	Peek   bool             `json:"peek"`
+}
+
+type BatchCheckItem struct {
+	ClientID string `json:"client_id" binding:"required"`
+	Action   string `json:"action" binding:"required"`
+	Tier     string `json:"tier"`
+	Cost     int64  `json:"cost" binding:"gte=0"`
 }
 
+type ResetRequest struct {
+	ClientID string `json:"client_id" binding:"required"`
+	// Action limits the reset to one action; empty resets every action
+	Action string `json:"action"`
+}
+
+// Check consumes cost requests for one client/action.
+// Denied checks return 429 unless peek is set, in which case the status is always 200.
 func (h *CheckHandler) Check(c *gin.Context) {
 	var req CheckRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -27,27 +56,116 @@ func (h *CheckHandler) Check(c *gin.Context) {
 		return
 	}
 
-	result, err := h.limiter.Check(c.Request.Context(), req.ClientID, req.Action)
+	item := limiter.CheckItem{ClientID: req.ClientID, Tier: req.Tier, Action: req.Action, Cost: req.Cost}
+	_, results, err := h.limiter.CheckBatch(c.Request.Context(), []limiter.CheckItem{item}, req.Peek)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	result := results[0]
 
 	status := http.StatusOK
-	if !result.Allowed {
+	if !result.Allowed && !req.Peek {
 		status = http.StatusTooManyRequests
 	}
 
 	c.JSON(status, result)
 }
 
+// CheckBatch evaluates several checks atomically: either every check consumes its
+// cost or, if any is denied, none does.
+func (h *CheckHandler) CheckBatch(c *gin.Context) {
+	var req BatchCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "checks must be a non-empty list of {client_id, action, cost}"})
+		return
+	}
+	if len(req.Checks) > maxBatchSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("at most %d checks per batch", maxBatchSize)})
+		return
+	}
+
+	items := make([]limiter.CheckItem, len(req.Checks))
+	for i, check := range req.Checks {
+		items[i] = limiter.CheckItem{ClientID: check.ClientID, Tier: check.Tier, Action: check.Action, Cost: check.Cost}
+	}
+
+	allowed, results, err := h.limiter.CheckBatch(c.Request.Context(), items, req.Peek)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	status := http.StatusOK
+	if !allowed && !req.Peek {
+		status = http.StatusTooManyRequests
+	}
+
+	c.JSON(status, gin.H{
+		"allowed": allowed,
+		"results": results,
+	})
+}
+
+// Reset clears a client's counters
+func (h *CheckHandler) Reset(c *gin.Context) {
+	var req ResetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "client_id is required"})
+		return
+	}
+
+	deleted, err := h.limiter.Reset(c.Request.Context(), req.ClientID, req.Action)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"client_id": req.ClientID,
+		"action":    req.Action,
+		"deleted":   deleted,
+	})
+}
+
+// GetLimits returns the per-action rules. With ?client_id= (and optionally ?tier=)
+// it returns the effective rules for that client after tier and client overrides.
 func (h *CheckHandler) GetLimits(c *gin.Context) {
-	limits := make(map[string]map[string]interface{})
-	for action, config := range limiter.DefaultLimits {
-		limits[action] = map[string]interface{}{
-			"limit":         config.Limit,
-			"window_seconds": int(config.Window.Seconds()),
+	policy := h.limiter.Policies().Policy()
+
+	clientID := c.Query("client_id")
+	if clientID == "" {
+		limits := make(map[string]map[string]interface{})
+		for action, config := range policy.Actions {
+			limits[action] = limitInfo(config)
 		}
+		c.JSON(http.StatusOK, limits)
+		return
+	}
+
+	tier := policy.TierFor(clientID, c.Query("tier"))
+	limits := make(map[string]map[string]interface{})
+	for action, config := range policy.Effective(clientID, tier) {
+		limits[action] = limitInfo(config)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"client_id": clientID,
+		"tier":      tier,
+		"limits":    limits,
+		"default":   limitInfo(policy.Resolve(clientID, tier, "")),
+	})
+}
+
+func limitInfo(config limiter.ActionConfig) map[string]interface{} {
+	info := map[string]interface{}{
+		"algorithm": config.Algorithm,
+		"limit":     config.MaxRequests(),
+	}
+	if config.Algorithm == limiter.AlgorithmTokenBucket {
+		info["burst"] = config.Burst
+		info["refill_rate"] = config.RefillRate
+	} else {
+		info["window_seconds"] = int(config.Window.Seconds())
 	}
-	c.JSON(http.StatusOK, limits)
+	return info
 }
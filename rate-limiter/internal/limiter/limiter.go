@@ -0,0 +1,204 @@
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/epikoding/etymograph/rate-limiter/internal/metrics"
+	"github.com/epikoding/etymograph/rate-limiter/internal/storage"
+)
+
+// Algorithm selects how an action's requests are counted
+type Algorithm string
+
+const (
+	// AlgorithmFixedWindow counts requests per fixed window; allows up to 2x Limit across a window boundary
+	AlgorithmFixedWindow Algorithm = "fixed_window"
+	// AlgorithmSlidingWindow allows Limit requests in any trailing Window
+	AlgorithmSlidingWindow Algorithm = "sliding_window"
+	// AlgorithmTokenBucket allows bursts of Burst requests, refilled at RefillRate per second
+	AlgorithmTokenBucket Algorithm = "token_bucket"
+)
+
+type ActionConfig struct {
+	Algorithm Algorithm `yaml:"algorithm"`
+
+	// Limit and Window apply to fixed_window and sliding_window
+	Limit  int64         `yaml:"limit"`
+	Window time.Duration `yaml:"window"`
+
+	// Burst (bucket capacity) and RefillRate (tokens per second) apply to token_bucket
+	Burst      int64   `yaml:"burst"`
+	RefillRate float64 `yaml:"refill_rate"`
+}
+
+// MaxRequests returns the number of requests a fresh client can make at once
+func (c ActionConfig) MaxRequests() int64 {
+	if c.Algorithm == AlgorithmTokenBucket {
+		return c.Burst
+	}
+	return c.Limit
+}
+
+// DefaultLimits are the built-in per-action rules used when no policy file is configured
+var DefaultLimits = map[string]ActionConfig{
+	"search":      {Algorithm: AlgorithmTokenBucket, Burst: 50, RefillRate: 50.0 / 60},
+	"etymology":   {Algorithm: AlgorithmTokenBucket, Burst: 30, RefillRate: 30.0 / 60},
+	"derivatives": {Algorithm: AlgorithmTokenBucket, Burst: 30, RefillRate: 30.0 / 60},
+	"synonyms":    {Algorithm: AlgorithmTokenBucket, Burst: 30, RefillRate: 30.0 / 60},
+	"export":      {Algorithm: AlgorithmSlidingWindow, Limit: 10, Window: time.Minute},
+}
+
+// DefaultActionConfig applies to actions missing from the policy
+var DefaultActionConfig = ActionConfig{Algorithm: AlgorithmSlidingWindow, Limit: 100, Window: time.Minute}
+
+// Store evaluates rate limit rules; implemented by storage.RedisStorage,
+// storage.MemoryStorage and storage.FailoverStorage
+type Store interface {
+	Eval(ctx context.Context, rules []storage.Rule, peek bool) (bool, []storage.Result, error)
+	DeleteMatching(ctx context.Context, pattern string) (int64, error)
+}
+
+type Limiter struct {
+	storage  Store
+	policies *PolicyStore
+}
+
+// CheckResult reports the decision for one request.
+// ResetAt is when the next request will be allowed if this one was denied,
+// otherwise when the full limit is available again.
+// Outside peek mode, Allowed is false for every item of a batch when any item was denied.
+type CheckResult struct {
+	Allowed   bool  `json:"allowed"`
+	Remaining int64 `json:"remaining"`
+	ResetAt   int64 `json:"reset_at"`
+	Limit     int64 `json:"limit"`
+}
+
+func NewLimiter(storage Store, policies *PolicyStore) *Limiter {
+	return &Limiter{storage: storage, policies: policies}
+}
+
+// Policies returns the store the limiter reads its rules from
+func (l *Limiter) Policies() *PolicyStore {
+	return l.policies
+}
+
+// CheckItem is one (client, action, cost) tuple of a batch
+type CheckItem struct {
+	ClientID string
+	Tier     string
+	Action   string
+	// Cost is how many requests the item consumes; values below 1 count as 1
+	Cost int64
+}
+
+// Check consumes one request for clientID/action. tier may be empty, in which case
+// it is taken from the policy or inferred from the client ID.
+func (l *Limiter) Check(ctx context.Context, clientID, tier, action string) (*CheckResult, error) {
+	_, results, err := l.CheckBatch(ctx, []CheckItem{{ClientID: clientID, Tier: tier, Action: action, Cost: 1}}, false)
+	if err != nil {
+		return nil, err
+	}
+	return results[0], nil
+}
+
+// CheckBatch evaluates all items atomically. Unless peek is set, every item's cost is
+// consumed when all items are allowed; if any item is denied nothing is consumed.
+// Items for the same client and action are combined so their costs add up.
+func (l *Limiter) CheckBatch(ctx context.Context, items []CheckItem, peek bool) (bool, []*CheckResult, error) {
+	policy := l.policies.Policy()
+
+	var rules []storage.Rule
+	configs := make([]ActionConfig, 0, len(items))
+	ruleIndex := make([]int, len(items))
+	byKey := make(map[string]int)
+
+	for i, item := range items {
+		config := policy.Resolve(item.ClientID, policy.TierFor(item.ClientID, item.Tier), item.Action)
+		cost := item.Cost
+		if cost < 1 {
+			cost = 1
+		}
+
+		key := rateKey(item.ClientID, item.Action, config.Algorithm)
+		if idx, ok := byKey[key]; ok {
+			rules[idx].Cost += cost
+			ruleIndex[i] = idx
+			continue
+		}
+
+		rule := storage.Rule{Key: key, Algorithm: string(config.Algorithm), Cost: cost}
+		switch config.Algorithm {
+		case AlgorithmTokenBucket:
+			rule.Limit = config.Burst
+			rule.Rate = config.RefillRate
+		case AlgorithmSlidingWindow, AlgorithmFixedWindow:
+			rule.Limit = config.Limit
+			rule.Window = config.Window
+		default:
+			return false, nil, fmt.Errorf("unknown algorithm %q for action %s", config.Algorithm, item.Action)
+		}
+
+		byKey[key] = len(rules)
+		ruleIndex[i] = len(rules)
+		rules = append(rules, rule)
+		configs = append(configs, config)
+	}
+
+	allowed, res, err := l.storage.Eval(ctx, rules, peek)
+	if err != nil {
+		for _, item := range items {
+			metrics.ChecksTotal.WithLabelValues(item.Action, "error").Inc()
+		}
+		return false, nil, fmt.Errorf("failed to evaluate rate limits: %w", err)
+	}
+
+	now := time.Now()
+	results := make([]*CheckResult, len(items))
+	for i := range items {
+		r := res[ruleIndex[i]]
+		wait := r.ResetAfter
+		if !r.Allowed {
+			wait = r.RetryAfter
+		}
+		results[i] = &CheckResult{
+			Allowed:   r.Allowed && (allowed || peek),
+			Remaining: r.Remaining,
+			ResetAt:   now.Add(wait).Add(time.Second - 1).Unix(),
+			Limit:     configs[ruleIndex[i]].MaxRequests(),
+		}
+		if !peek {
+			result := "allowed"
+			if !results[i].Allowed {
+				result = "denied"
+			}
+			metrics.ChecksTotal.WithLabelValues(items[i].Action, result).Inc()
+		}
+	}
+
+	return allowed, results, nil
+}
+
+// Reset clears a client's counters for one action, or for every action when action is empty
+func (l *Limiter) Reset(ctx context.Context, clientID, action string) (int64, error) {
+	if action == "" {
+		action = "*"
+	} else {
+		action = escapePattern(action)
+	}
+	return l.storage.DeleteMatching(ctx, fmt.Sprintf("rate:%s:%s:*", escapePattern(clientID), action))
+}
+
+// rateKey builds the Redis key of a counter. The algorithm is part of the key so
+// switching it never reads another algorithm's state.
+func rateKey(clientID, action string, algorithm Algorithm) string {
+	return fmt.Sprintf("rate:%s:%s:%s", clientID, action, algorithm)
+}
+
+// escapePattern escapes Redis glob characters
+func escapePattern(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`, "]", `\]`).Replace(s)
+}
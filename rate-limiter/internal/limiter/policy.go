@@ -0,0 +1,281 @@
+package limiter
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Tiers a client can belong to
+const (
+	TierAnonymous = "anonymous"
+	TierUser      = "user"
+	TierAdmin     = "admin"
+	TierInternal  = "internal"
+)
+
+// Policy is the full set of rate limit rules.
+// An action's effective config is built from Actions (or Default), then the
+// client's tier override, then the client's own override; each layer only
+// replaces the fields it sets.
+type Policy struct {
+	Default ActionConfig                       `yaml:"default"`
+	Actions map[string]ActionConfig            `yaml:"actions"`
+	Tiers   map[string]map[string]ActionConfig `yaml:"tiers"`
+	Clients map[string]ClientPolicy            `yaml:"clients"`
+}
+
+// ClientPolicy pins a single client to a tier and/or overrides its actions
+type ClientPolicy struct {
+	Tier    string                  `yaml:"tier"`
+	Actions map[string]ActionConfig `yaml:"actions"`
+}
+
+// DefaultPolicy is used when no policy file is configured
+func DefaultPolicy() *Policy {
+	actions := make(map[string]ActionConfig, len(DefaultLimits))
+	for action, config := range DefaultLimits {
+		actions[action] = config
+	}
+	return &Policy{
+		Default: DefaultActionConfig,
+		Actions: actions,
+	}
+}
+
+// ParsePolicy parses a YAML or JSON policy and validates every rule in it
+func ParsePolicy(data []byte) (*Policy, error) {
+	var p Policy
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse policy: %w", err)
+	}
+	if p.Default.Algorithm == "" {
+		p.Default = DefaultActionConfig
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Validate resolves every action for every tier and client and checks the result is usable
+func (p *Policy) Validate() error {
+	if err := p.Default.validate(); err != nil {
+		return fmt.Errorf("default: %w", err)
+	}
+	for action := range p.actionNames(TierAnonymous, "") {
+		if err := p.Resolve("", TierAnonymous, action).validate(); err != nil {
+			return fmt.Errorf("actions.%s: %w", action, err)
+		}
+	}
+	for tier := range p.Tiers {
+		for action := range p.actionNames(tier, "") {
+			if err := p.Resolve("", tier, action).validate(); err != nil {
+				return fmt.Errorf("tiers.%s.%s: %w", tier, action, err)
+			}
+		}
+	}
+	for clientID := range p.Clients {
+		tier := p.TierFor(clientID, "")
+		for action := range p.actionNames(tier, clientID) {
+			if err := p.Resolve(clientID, tier, action).validate(); err != nil {
+				return fmt.Errorf("clients.%s.%s: %w", clientID, action, err)
+			}
+		}
+	}
+	return nil
+}
+
+// TierFor returns the tier of a client: a tier pinned in Clients wins, then the tier
+// requested by the caller, then one inferred from the client ID prefix
+// ("user:<id>" is user, "internal:<name>" is internal, everything else anonymous).
+func (p *Policy) TierFor(clientID, requested string) string {
+	if client, ok := p.Clients[clientID]; ok && client.Tier != "" {
+		return client.Tier
+	}
+	if requested != "" {
+		return requested
+	}
+	switch {
+	case strings.HasPrefix(clientID, "user:"):
+		return TierUser
+	case strings.HasPrefix(clientID, "internal:"):
+		return TierInternal
+	default:
+		return TierAnonymous
+	}
+}
+
+// Resolve returns the effective config for a client's action in the given tier
+func (p *Policy) Resolve(clientID, tier, action string) ActionConfig {
+	config, ok := p.Actions[action]
+	if !ok {
+		config = p.Default
+	}
+	if override, ok := p.Tiers[tier][action]; ok {
+		config = config.merge(override)
+	}
+	if override, ok := p.Clients[clientID].Actions[action]; ok {
+		config = config.merge(override)
+	}
+	return config
+}
+
+// Effective returns the resolved config of every known action for a client
+func (p *Policy) Effective(clientID, tier string) map[string]ActionConfig {
+	effective := make(map[string]ActionConfig)
+	for action := range p.actionNames(tier, clientID) {
+		effective[action] = p.Resolve(clientID, tier, action)
+	}
+	return effective
+}
+
+func (p *Policy) actionNames(tier, clientID string) map[string]bool {
+	names := make(map[string]bool)
+	for action := range p.Actions {
+		names[action] = true
+	}
+	for action := range p.Tiers[tier] {
+		names[action] = true
+	}
+	for action := range p.Clients[clientID].Actions {
+		names[action] = true
+	}
+	return names
+}
+
+// merge returns c with every non-zero field of override applied
+func (c ActionConfig) merge(override ActionConfig) ActionConfig {
+	if override.Algorithm != "" {
+		c.Algorithm = override.Algorithm
+	}
+	if override.Limit != 0 {
+		c.Limit = override.Limit
+	}
+	if override.Window != 0 {
+		c.Window = override.Window
+	}
+	if override.Burst != 0 {
+		c.Burst = override.Burst
+	}
+	if override.RefillRate != 0 {
+		c.RefillRate = override.RefillRate
+	}
+	return c
+}
+
+func (c ActionConfig) validate() error {
+	switch c.Algorithm {
+	case AlgorithmTokenBucket:
+		if c.Burst <= 0 || c.RefillRate <= 0 {
+			return fmt.Errorf("token_bucket requires positive burst and refill_rate")
+		}
+	case AlgorithmFixedWindow, AlgorithmSlidingWindow:
+		if c.Limit <= 0 || c.Window <= 0 {
+			return fmt.Errorf("%s requires positive limit and window", c.Algorithm)
+		}
+	default:
+		return fmt.Errorf("unknown algorithm %q", c.Algorithm)
+	}
+	return nil
+}
+
+// PolicyStore holds the current policy and swaps it atomically on reload
+type PolicyStore struct {
+	path    string
+	current atomic.Pointer[Policy]
+
+	mu      sync.Mutex
+	modTime time.Time
+}
+
+// NewPolicyStore loads the policy at path, or DefaultPolicy when path is empty
+func NewPolicyStore(path string) (*PolicyStore, error) {
+	s := &PolicyStore{path: path}
+	if path == "" {
+		s.current.Store(DefaultPolicy())
+		return s, nil
+	}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Policy returns the current policy
+func (s *PolicyStore) Policy() *Policy {
+	return s.current.Load()
+}
+
+// Reload re-reads the policy file. On error the previous policy stays active.
+func (s *PolicyStore) Reload() error {
+	if s.path == "" {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to stat policy file: %w", err)
+	}
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to read policy file: %w", err)
+	}
+	policy, err := ParsePolicy(data)
+	if err != nil {
+		return err
+	}
+
+	s.current.Store(policy)
+	s.modTime = info.ModTime()
+	return nil
+}
+
+// Watch reloads the policy whenever reload receives a value (e.g. SIGHUP)
+// and whenever the file's modification time changes, checked every interval.
+// It returns when done is closed.
+func (s *PolicyStore) Watch(reload <-chan os.Signal, interval time.Duration, done <-chan struct{}) {
+	if s.path == "" {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-reload:
+			s.reloadAndLog("signal")
+		case <-ticker.C:
+			info, err := os.Stat(s.path)
+			if err != nil {
+				continue
+			}
+			s.mu.Lock()
+			changed := !info.ModTime().Equal(s.modTime)
+			s.mu.Unlock()
+			if changed {
+				s.reloadAndLog("file change")
+			}
+		}
+	}
+}
+
+func (s *PolicyStore) reloadAndLog(trigger string) {
+	if err := s.Reload(); err != nil {
+		log.Printf("Policy reload (%s) failed, keeping previous policy: %v", trigger, err)
+		return
+	}
+	log.Printf("Policy reloaded from %s (%s)", s.path, trigger)
+}
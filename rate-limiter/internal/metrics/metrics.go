@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// Checks by action and decision (allowed, denied, error)
+	ChecksTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rate_limiter_checks_total",
+			Help: "Total number of rate limit checks",
+		},
+		[]string{"action", "result"},
+	)
+
+	// Redis calls that failed and were served by the in-memory fallback
+	RedisErrorsTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "rate_limiter_redis_errors_total",
+			Help: "Total number of failed Redis rate limit calls",
+		},
+	)
+
+	// Calls served by the in-memory fallback
+	FallbackEvalsTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "rate_limiter_fallback_evals_total",
+			Help: "Total number of rate limit evaluations served by the in-memory fallback",
+		},
+	)
+
+	// 1 while the circuit breaker is open and checks use the in-memory fallback
+	Degraded = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "rate_limiter_degraded",
+			Help: "Whether the rate limiter is in degraded (in-memory) mode",
+		},
+	)
+
+	// Total time spent in degraded mode
+	DegradedSecondsTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "rate_limiter_degraded_seconds_total",
+			Help: "Total seconds spent in degraded (in-memory) mode",
+		},
+	)
+)
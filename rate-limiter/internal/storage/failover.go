@@ -0,0 +1,219 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/epikoding/etymograph/rate-limiter/internal/metrics"
+)
+
+// FailoverStorage uses Redis and falls back to a MemoryStorage when Redis fails.
+// A circuit breaker opens after FailureThreshold consecutive errors; while open,
+// every call goes to memory. After Cooldown one call is let through to Redis
+// (half-open) and the breaker closes again if it succeeds.
+//
+// Each Redis call is bounded by its own timeout, at most half of the caller's remaining
+// time, so a Redis that hangs instead of refusing connections still counts as failing
+// and leaves time to answer from memory.
+type FailoverStorage struct {
+	redis    *RedisStorage
+	fallback *MemoryStorage
+
+	failureThreshold int
+	cooldown         time.Duration
+	timeout          time.Duration
+
+	mu            sync.Mutex
+	failures      int
+	open          bool
+	openedAt      time.Time
+	degradedSince time.Time
+	probing       bool
+	lastAccounted time.Time
+}
+
+// NewFailoverStorage wraps redis; timeout bounds each Redis call (0 = only the caller's deadline)
+func NewFailoverStorage(redis *RedisStorage, failureThreshold int, cooldown, timeout time.Duration) *FailoverStorage {
+	if failureThreshold < 1 {
+		failureThreshold = 1
+	}
+	return &FailoverStorage{
+		redis:            redis,
+		fallback:         NewMemoryStorage(),
+		failureThreshold: failureThreshold,
+		cooldown:         cooldown,
+		timeout:          timeout,
+	}
+}
+
+// Degrade opens the breaker immediately, e.g. when Redis is unreachable at startup
+func (s *FailoverStorage) Degrade(reason error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.trip(reason)
+}
+
+// Degraded reports whether calls are currently served from memory
+func (s *FailoverStorage) Degraded() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.account(time.Now())
+	return s.open
+}
+
+// Eval runs rules on Redis, or on memory while the breaker is open or Redis fails.
+// A Redis timeout counts as a failure even if the caller's deadline has passed too;
+// other errors after the caller gave up are returned as is, without counting them
+// against Redis or consulting the fallback.
+func (s *FailoverStorage) Eval(ctx context.Context, rules []Rule, peek bool) (bool, []Result, error) {
+	if !s.useRedis() {
+		metrics.FallbackEvalsTotal.Inc()
+		return s.fallback.Eval(ctx, rules, peek)
+	}
+
+	redisCtx, cancel := s.redisContext(ctx)
+	allowed, results, err := s.redis.Eval(redisCtx, rules, peek)
+	cancel()
+	if err != nil && ctx.Err() != nil && !redisTimedOut(ctx, err) {
+		// The caller gave up; that says nothing about Redis
+		s.release()
+		return false, nil, err
+	}
+	s.record(err)
+	if err != nil {
+		metrics.FallbackEvalsTotal.Inc()
+		return s.fallback.Eval(ctx, rules, peek)
+	}
+	return allowed, results, nil
+}
+
+// DeleteMatching clears both Redis and the fallback. A Redis error is returned
+// unless the breaker is open, in which case only memory is cleared.
+// Errors from the caller's own cancellation or deadline do not count against Redis.
+// DeleteMatching scans every key, so it is bounded by the caller's context only.
+func (s *FailoverStorage) DeleteMatching(ctx context.Context, pattern string) (int64, error) {
+	deleted, _ := s.fallback.DeleteMatching(ctx, pattern)
+	if !s.useRedis() {
+		return deleted, nil
+	}
+
+	n, err := s.redis.DeleteMatching(ctx, pattern)
+	if err != nil && ctx.Err() != nil {
+		s.release()
+		return deleted + n, err
+	}
+	s.record(err)
+	return deleted + n, err
+}
+
+func (s *FailoverStorage) Close() error {
+	return s.redis.Close()
+}
+
+// redisContext bounds a Redis call by the storage timeout and by half of the time left
+// before ctx's deadline
+func (s *FailoverStorage) redisContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout := s.timeout
+	if deadline, ok := ctx.Deadline(); ok {
+		if half := time.Until(deadline) / 2; timeout <= 0 || half < timeout {
+			timeout = half
+		}
+	}
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// redisTimedOut reports whether err is Redis failing to answer in time rather than
+// the caller going away: the call's own timeout fired, or a network timeout
+func redisTimedOut(ctx context.Context, err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) && !errors.Is(ctx.Err(), context.Canceled) {
+		return true
+	}
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
+
+// useRedis reports whether this call should go to Redis. While open, exactly one
+// call after the cooldown is allowed through as a probe.
+func (s *FailoverStorage) useRedis() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.account(now)
+	if !s.open {
+		return true
+	}
+	if s.probing || now.Sub(s.openedAt) < s.cooldown {
+		return false
+	}
+	s.probing = true
+	return true
+}
+
+// release ends a call without recording a result, so a probe cut short by its
+// caller's context lets the next call probe instead
+func (s *FailoverStorage) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.probing = false
+}
+
+func (s *FailoverStorage) record(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.account(now)
+
+	if err == nil {
+		if s.open {
+			log.Printf("Redis recovered after %s, leaving degraded mode", now.Sub(s.degradedSince).Round(time.Second))
+			metrics.Degraded.Set(0)
+		}
+		s.failures = 0
+		s.open = false
+		s.probing = false
+		return
+	}
+
+	metrics.RedisErrorsTotal.Inc()
+	s.failures++
+	if s.open {
+		// failed probe: wait another cooldown
+		s.openedAt = now
+		s.probing = false
+		return
+	}
+	if s.failures >= s.failureThreshold {
+		s.trip(err)
+	}
+}
+
+// trip opens the breaker; callers hold mu
+func (s *FailoverStorage) trip(reason error) {
+	now := time.Now()
+	if !s.open {
+		log.Printf("Redis unavailable, using in-memory fallback limiter: %v", reason)
+		s.lastAccounted = now
+		s.degradedSince = now
+	}
+	s.open = true
+	s.openedAt = now
+	s.probing = false
+	metrics.Degraded.Set(1)
+}
+
+// account adds the time spent degraded since the last call to the metric; callers hold mu
+func (s *FailoverStorage) account(now time.Time) {
+	if s.open {
+		metrics.DegradedSecondsTotal.Add(now.Sub(s.lastAccounted).Seconds())
+	}
+	s.lastAccounted = now
+}
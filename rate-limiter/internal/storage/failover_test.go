@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// hungRedis accepts connections and never answers, like a Redis that is blackholed
+// or stuck rather than refusing connections
+func hungRedis(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(io.Discard, conn)
+			}()
+		}
+	}()
+	return "redis://" + ln.Addr().String()
+}
+
+func newHungFailover(t *testing.T, threshold int) *FailoverStorage {
+	t.Helper()
+	redisStorage, err := NewRedisStorage(hungRedis(t))
+	if err != nil {
+		t.Fatalf("NewRedisStorage: %v", err)
+	}
+	s := NewFailoverStorage(redisStorage, threshold, time.Minute, 200*time.Millisecond)
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+var failoverRules = []Rule{{Key: "test:failover", Algorithm: "fixed_window", Limit: 10, Window: time.Minute, Cost: 1}}
+
+func TestFailoverHungRedisFallsBackWithinCallerDeadline(t *testing.T) {
+	s := newHungFailover(t, 2)
+
+	for i := 0; i < 2; i++ {
+		// Shorter than the client's 500ms read timeout, like api-go's default budget
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		allowed, results, err := s.Eval(ctx, failoverRules, false)
+		cancel()
+		if err != nil {
+			t.Fatalf("call %d: Eval: %v, want an answer from memory", i, err)
+		}
+		if !allowed || len(results) != 1 {
+			t.Fatalf("call %d: allowed=%v results=%v", i, allowed, results)
+		}
+	}
+	if !s.Degraded() {
+		t.Error("breaker did not open after Redis timed out")
+	}
+}
+
+func TestFailoverCallerCancellationIsNotARedisFailure(t *testing.T) {
+	s := newHungFailover(t, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := s.Eval(ctx, failoverRules, false); err == nil {
+		t.Error("Eval succeeded for a cancelled caller")
+	}
+	if s.Degraded() {
+		t.Error("caller cancellation opened the breaker")
+	}
+}
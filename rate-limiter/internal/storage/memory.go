@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"context"
+	"math"
+	"path"
+	"sync"
+	"time"
+)
+
+// MemoryStorage is a process-local fallback used while Redis is unavailable.
+// Every rule is approximated by a token bucket: token_bucket rules keep their
+// capacity and refill rate, window rules get capacity Limit refilled over Window.
+// Counters are not shared between replicas, so limits are per instance.
+type MemoryStorage struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastEvict time.Time
+	now       func() time.Time
+}
+
+// memoryEvictInterval is how often full buckets are dropped
+const memoryEvictInterval = time.Minute
+
+type memoryBucket struct {
+	tokens  float64
+	updated time.Time
+	// full is when the bucket is back at capacity; used to evict idle buckets
+	full time.Time
+}
+
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		buckets: make(map[string]*memoryBucket),
+		now:     time.Now,
+	}
+}
+
+// Eval has the same all-or-nothing semantics as RedisStorage.Eval
+func (s *MemoryStorage) Eval(ctx context.Context, rules []Rule, peek bool) (bool, []Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.evict(now)
+
+	levels := make([]float64, len(rules))
+	results := make([]Result, len(rules))
+	all := true
+
+	for i, rule := range rules {
+		capacity, rate := memoryBucketParams(rule)
+		tokens := capacity
+		if b, ok := s.buckets[rule.Key]; ok {
+			tokens = math.Min(capacity, b.tokens+now.Sub(b.updated).Seconds()*rate)
+		}
+		levels[i] = tokens
+
+		results[i].Allowed = tokens >= float64(rule.Cost)
+		if !results[i].Allowed {
+			all = false
+			results[i].RetryAfter = secondsToDuration((float64(rule.Cost) - tokens) / rate)
+		}
+	}
+
+	apply := all && !peek
+	for i, rule := range rules {
+		capacity, rate := memoryBucketParams(rule)
+		tokens := levels[i]
+		if apply {
+			tokens -= float64(rule.Cost)
+			s.buckets[rule.Key] = &memoryBucket{
+				tokens:  tokens,
+				updated: now,
+				full:    now.Add(secondsToDuration((capacity - tokens) / rate)),
+			}
+		}
+		results[i].Remaining = int64(math.Floor(tokens))
+		results[i].ResetAfter = secondsToDuration((capacity - tokens) / rate)
+	}
+
+	return all, results, nil
+}
+
+// DeleteMatching deletes every bucket whose key matches a Redis-style glob pattern
+func (s *MemoryStorage) DeleteMatching(ctx context.Context, pattern string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deleted int64
+	for key := range s.buckets {
+		if ok, _ := path.Match(pattern, key); ok {
+			delete(s.buckets, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// evict drops buckets that have refilled completely; they are equivalent to missing ones
+func (s *MemoryStorage) evict(now time.Time) {
+	if now.Sub(s.lastEvict) < memoryEvictInterval {
+		return
+	}
+	s.lastEvict = now
+	for key, b := range s.buckets {
+		if !now.Before(b.full) {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+func memoryBucketParams(rule Rule) (capacity, rate float64) {
+	capacity = float64(rule.Limit)
+	if rule.Algorithm == "token_bucket" {
+		return capacity, rule.Rate
+	}
+	return capacity, capacity / rule.Window.Seconds()
+}
+
+func secondsToDuration(s float64) time.Duration {
+	return time.Duration(math.Ceil(s * float64(time.Second)))
+}
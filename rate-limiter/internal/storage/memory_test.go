@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+// newTestMemory returns a MemoryStorage and a function that moves its clock
+func newTestMemory() (*MemoryStorage, func(time.Duration)) {
+	s := NewMemoryStorage()
+	now := time.Unix(1700000000, 0)
+	s.now = func() time.Time { return now }
+	return s, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestMemoryWindowRuleRefillsOverWindow(t *testing.T) {
+	s, advance := newTestMemory()
+	// Approximated as a bucket of 10 refilling 1 per second
+	rule := Rule{Key: "test:memory", Algorithm: "fixed_window", Limit: 10, Window: 10 * time.Second, Cost: 5}
+
+	for i := 0; i < 2; i++ {
+		if allowed, _ := eval(t, s, false, rule); !allowed {
+			t.Fatalf("request %d denied within limit", i+1)
+		}
+	}
+	allowed, results := eval(t, s, false, rule)
+	if allowed || results[0].RetryAfter != 5*time.Second {
+		t.Errorf("over limit: allowed=%v RetryAfter=%v, want denied for 5s", allowed, results[0].RetryAfter)
+	}
+	if results[0].ResetAfter != 10*time.Second {
+		t.Errorf("ResetAfter = %v, want 10s", results[0].ResetAfter)
+	}
+
+	advance(5 * time.Second)
+	if allowed, results := eval(t, s, false, rule); !allowed || results[0].Remaining != 0 {
+		t.Errorf("after refill: allowed=%v Remaining=%d, want allowed with 0 left", allowed, results[0].Remaining)
+	}
+}
+
+func TestMemoryBatchIsAllOrNothing(t *testing.T) {
+	s, _ := newTestMemory()
+	open := Rule{Key: "test:batch:open", Algorithm: "token_bucket", Limit: 5, Rate: 1, Cost: 1}
+	full := Rule{Key: "test:batch:full", Algorithm: "sliding_window", Limit: 1, Window: time.Minute, Cost: 1}
+
+	if allowed, _ := eval(t, s, false, full); !allowed {
+		t.Fatal("first request denied")
+	}
+	allowed, results := eval(t, s, false, open, full)
+	if allowed || !results[0].Allowed || results[1].Allowed {
+		t.Fatalf("allowed=%v per rule %v %v, want denied by the second rule only", allowed, results[0].Allowed, results[1].Allowed)
+	}
+	if _, results := eval(t, s, true, open); results[0].Remaining != 5 {
+		t.Errorf("Remaining = %d after a denied batch, want 5", results[0].Remaining)
+	}
+}
+
+func TestMemoryPeekDoesNotConsume(t *testing.T) {
+	s, _ := newTestMemory()
+	rule := Rule{Key: "test:peek", Algorithm: "token_bucket", Limit: 1, Rate: 1, Cost: 1}
+
+	for i := 0; i < 3; i++ {
+		if allowed, results := eval(t, s, true, rule); !allowed || results[0].Remaining != 1 {
+			t.Fatalf("peek %d: allowed=%v Remaining=%d, want allowed with 1 left", i+1, allowed, results[0].Remaining)
+		}
+	}
+	if allowed, _ := eval(t, s, false, rule); !allowed {
+		t.Fatal("consume denied after peeks")
+	}
+	if allowed, _ := eval(t, s, true, rule); allowed {
+		t.Error("peek allowed after the limit was consumed")
+	}
+}
+
+func TestMemoryEvictsRefilledBuckets(t *testing.T) {
+	s, advance := newTestMemory()
+	rule := Rule{Key: "test:evict", Algorithm: "token_bucket", Limit: 2, Rate: 1, Cost: 1}
+
+	eval(t, s, false, rule)
+	advance(memoryEvictInterval)
+	eval(t, s, true, Rule{Key: "test:other", Algorithm: "token_bucket", Limit: 1, Rate: 1, Cost: 1})
+	if _, ok := s.buckets[rule.Key]; ok {
+		t.Error("refilled bucket was not evicted")
+	}
+}
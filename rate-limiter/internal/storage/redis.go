@@ -3,6 +3,8 @@ package storage
 import (
 	"context"
 	"fmt"
+	"math/rand"
+	"strconv"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -12,37 +14,254 @@ type RedisStorage struct {
 	client *redis.Client
 }
 
+// Rule is one counter to evaluate in Eval
+type Rule struct {
+	Key string
+	// Algorithm is "token_bucket", "sliding_window" or "fixed_window"
+	Algorithm string
+	// Limit is the bucket capacity (token_bucket) or the requests allowed per window
+	Limit int64
+	// Rate is the refill rate in tokens per second (token_bucket)
+	Rate float64
+	// Window is the window length (sliding_window, fixed_window)
+	Window time.Duration
+	// Cost is how many requests this rule consumes
+	Cost int64
+}
+
+// Result is the outcome for one Rule
+type Result struct {
+	Allowed   bool
+	Remaining int64
+	// RetryAfter is how long until Cost would be allowed (0 when allowed)
+	RetryAfter time.Duration
+	// ResetAfter is how long until the full limit is available again
+	ResetAfter time.Duration
+}
+
+// evalScript evaluates every rule first and only then, if all of them allow the
+// request and the mode is "consume", applies them, so a batch is all-or-nothing.
+// The clock is read with TIME so every rate-limiter replica shares Redis' clock.
+// Timestamps are in milliseconds.
+//
+// KEYS[i] = counter key of rule i
+// ARGV[1] = "consume" or "peek", ARGV[2] = unique prefix for sliding window log entries
+// ARGV[3 + 4*(i-1) ...] = algorithm, limit, rate or window (ms), cost
+// Returns {all allowed, then per rule: allowed, remaining, retry (ms), reset (ms)}
+var evalScript = redis.NewScript(`
+local mode = ARGV[1]
+local member = ARGV[2]
+
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+local rules = {}
+local all = true
+
+for i, key in ipairs(KEYS) do
+	local base = 2 + (i - 1) * 4
+	local r = {
+		algorithm = ARGV[base + 1],
+		limit = tonumber(ARGV[base + 2]),
+		param = tonumber(ARGV[base + 3]),
+		cost = tonumber(ARGV[base + 4]),
+		retry = 0,
+	}
+
+	if r.algorithm == 'token_bucket' then
+		local state = redis.call('HMGET', key, 'tokens', 'ts')
+		local tokens = tonumber(state[1])
+		local ts = tonumber(state[2])
+		if tokens == nil or ts == nil then
+			tokens = r.limit
+			ts = now
+		end
+		r.level = math.min(r.limit, tokens + math.max(now - ts, 0) * r.param / 1000)
+		r.allowed = r.level >= r.cost
+		if not r.allowed then
+			r.retry = math.ceil((r.cost - r.level) * 1000 / r.param)
+		end
+	elseif r.algorithm == 'sliding_window' then
+		local since = '(' .. (now - r.param)
+		r.level = redis.call('ZCOUNT', key, since, '+inf')
+		r.allowed = r.level + r.cost <= r.limit
+		if not r.allowed then
+			-- wait until enough of the oldest entries leave the window
+			local idx = r.level + r.cost - r.limit - 1
+			if idx < r.level then
+				local entry = redis.call('ZRANGEBYSCORE', key, since, '+inf', 'WITHSCORES', 'LIMIT', idx, 1)
+				r.retry = math.max(tonumber(entry[2]) + r.param - now, 0)
+			else
+				r.retry = r.param
+			end
+		end
+	else
+		r.level = tonumber(redis.call('GET', key) or '0')
+		r.ttl = math.max(redis.call('PTTL', key), 0)
+		r.allowed = r.level + r.cost <= r.limit
+		if not r.allowed then
+			if r.ttl > 0 then
+				r.retry = r.ttl
+			else
+				r.retry = r.param
+			end
+		end
+	end
+
+	if not r.allowed then
+		all = false
+	end
+	rules[i] = r
+end
+
+local apply = all and mode == 'consume'
+local out = {0}
+if all then
+	out[1] = 1
+end
+
+for i, r in ipairs(rules) do
+	local key = KEYS[i]
+	local remaining = 0
+	local reset = 0
+
+	if r.algorithm == 'token_bucket' then
+		local tokens = r.level
+		if apply then
+			tokens = tokens - r.cost
+			redis.call('HSET', key, 'tokens', tostring(tokens), 'ts', now)
+			redis.call('PEXPIRE', key, math.max(math.ceil((r.limit - tokens) * 1000 / r.param), 1000))
+		end
+		remaining = math.floor(tokens)
+		reset = math.ceil((r.limit - tokens) * 1000 / r.param)
+	elseif r.algorithm == 'sliding_window' then
+		local count = r.level
+		if apply then
+			redis.call('ZREMRANGEBYSCORE', key, '-inf', now - r.param)
+			for j = 1, r.cost do
+				redis.call('ZADD', key, now, member .. ':' .. i .. ':' .. j)
+			end
+			redis.call('PEXPIRE', key, r.param)
+			count = count + r.cost
+		end
+		remaining = math.max(r.limit - count, 0)
+		local newest = redis.call('ZRANGE', key, -1, -1, 'WITHSCORES')
+		if newest[2] then
+			reset = math.max(tonumber(newest[2]) + r.param - now, 0)
+		end
+	else
+		local count = r.level
+		reset = r.ttl
+		if apply then
+			count = redis.call('INCRBY', key, r.cost)
+			-- the TTL is only set when the window starts, so later hits do not extend it
+			if redis.call('PTTL', key) < 0 then
+				redis.call('PEXPIRE', key, r.param)
+				reset = r.param
+			end
+		end
+		remaining = math.max(r.limit - count, 0)
+	end
+
+	local allowed = 0
+	if r.allowed then
+		allowed = 1
+	end
+	table.insert(out, allowed)
+	table.insert(out, remaining)
+	table.insert(out, r.retry)
+	table.insert(out, reset)
+end
+
+return out
+`)
+
+// NewRedisStorage creates the Redis client. It does not connect; use Ping to check reachability.
 func NewRedisStorage(redisURL string) (*RedisStorage, error) {
 	opt, err := redis.ParseURL(redisURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse redis URL: %w", err)
 	}
 
-	client := redis.NewClient(opt)
+	// Fail fast so the in-memory fallback takes over quickly when Redis is down
+	if opt.DialTimeout == 0 {
+		opt.DialTimeout = time.Second
+	}
+	if opt.ReadTimeout == 0 {
+		opt.ReadTimeout = 500 * time.Millisecond
+	}
+	if opt.WriteTimeout == 0 {
+		opt.WriteTimeout = 500 * time.Millisecond
+	}
+	if opt.MaxRetries == 0 {
+		opt.MaxRetries = 1
+	}
 
-	// Test connection
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	return &RedisStorage{client: redis.NewClient(opt)}, nil
+}
 
-	if err := client.Ping(ctx).Err(); err != nil {
-		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+// Ping checks the connection to Redis
+func (s *RedisStorage) Ping(ctx context.Context) error {
+	if err := s.client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("failed to connect to redis: %w", err)
 	}
-
-	return &RedisStorage{client: client}, nil
+	return nil
 }
 
-func (s *RedisStorage) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
-	pipe := s.client.Pipeline()
+// Eval evaluates rules atomically. Unless peek is set, every rule consumes its Cost
+// when all of them allow it; if any rule denies, nothing is consumed.
+// It reports whether all rules allowed the request and the per-rule results.
+func (s *RedisStorage) Eval(ctx context.Context, rules []Rule, peek bool) (bool, []Result, error) {
+	mode := "consume"
+	if peek {
+		mode = "peek"
+	}
 
-	incr := pipe.Incr(ctx, key)
-	pipe.Expire(ctx, key, ttl)
+	keys := make([]string, len(rules))
+	args := []interface{}{mode, strconv.FormatInt(time.Now().UnixNano(), 10) + "-" + strconv.FormatInt(rand.Int63(), 36)}
+	for i, rule := range rules {
+		keys[i] = rule.Key
+		param := strconv.FormatInt(rule.Window.Milliseconds(), 10)
+		if rule.Algorithm == "token_bucket" {
+			param = strconv.FormatFloat(rule.Rate, 'f', -1, 64)
+		}
+		args = append(args, rule.Algorithm, rule.Limit, param, rule.Cost)
+	}
 
-	_, err := pipe.Exec(ctx)
+	vals, err := evalScript.Run(ctx, s.client, keys, args...).Int64Slice()
 	if err != nil {
-		return 0, err
+		return false, nil, err
+	}
+	if len(vals) != 1+4*len(rules) {
+		return false, nil, fmt.Errorf("unexpected script result length %d", len(vals))
+	}
+
+	results := make([]Result, len(rules))
+	for i := range rules {
+		v := vals[1+4*i:]
+		results[i] = Result{
+			Allowed:    v[0] == 1,
+			Remaining:  v[1],
+			RetryAfter: time.Duration(v[2]) * time.Millisecond,
+			ResetAfter: time.Duration(v[3]) * time.Millisecond,
+		}
 	}
 
-	return incr.Val(), nil
+	return vals[0] == 1, results, nil
+}
+
+// DeleteMatching deletes every key matching a glob pattern and returns how many were removed
+func (s *RedisStorage) DeleteMatching(ctx context.Context, pattern string) (int64, error) {
+	var deleted int64
+	iter := s.client.Scan(ctx, 0, pattern, 100).Iterator()
+	for iter.Next(ctx) {
+		n, err := s.client.Del(ctx, iter.Val()).Result()
+		if err != nil {
+			return deleted, err
+		}
+		deleted += n
+	}
+	return deleted, iter.Err()
 }
 
 func (s *RedisStorage) Get(ctx context.Context, key string) (int64, error) {
@@ -0,0 +1,206 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// testRedis is a RedisStorage against miniredis with a clock the test moves: the
+// script reads it through TIME and key expiry follows it
+type testRedis struct {
+	*RedisStorage
+	server *miniredis.Miniredis
+	now    time.Time
+}
+
+func newTestRedis(t *testing.T) *testRedis {
+	t.Helper()
+	server := miniredis.RunT(t)
+	s, err := NewRedisStorage("redis://" + server.Addr())
+	if err != nil {
+		t.Fatalf("NewRedisStorage: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	r := &testRedis{RedisStorage: s, server: server, now: time.Unix(1700000000, 0)}
+	server.SetTime(r.now)
+	return r
+}
+
+func (r *testRedis) advance(d time.Duration) {
+	r.now = r.now.Add(d)
+	r.server.SetTime(r.now)
+	r.server.FastForward(d)
+}
+
+// eval runs one Eval and fails the test on error
+func eval(t *testing.T, s interface {
+	Eval(ctx context.Context, rules []Rule, peek bool) (bool, []Result, error)
+}, peek bool, rules ...Rule) (bool, []Result) {
+	t.Helper()
+	allowed, results, err := s.Eval(context.Background(), rules, peek)
+	if err != nil {
+		t.Fatalf("Eval: %v", err)
+	}
+	if len(results) != len(rules) {
+		t.Fatalf("Eval returned %d results for %d rules", len(results), len(rules))
+	}
+	return allowed, results
+}
+
+func TestRedisTokenBucketRefills(t *testing.T) {
+	r := newTestRedis(t)
+	rule := Rule{Key: "test:token", Algorithm: "token_bucket", Limit: 2, Rate: 1, Cost: 1}
+
+	for i := 0; i < 2; i++ {
+		if allowed, _ := eval(t, r, false, rule); !allowed {
+			t.Fatalf("request %d denied within capacity", i+1)
+		}
+	}
+	allowed, results := eval(t, r, false, rule)
+	if allowed {
+		t.Fatal("request allowed with an empty bucket")
+	}
+	if got := results[0].RetryAfter; got != time.Second {
+		t.Errorf("RetryAfter = %v, want 1s for one token at 1/s", got)
+	}
+
+	r.advance(500 * time.Millisecond)
+	if allowed, results := eval(t, r, false, rule); allowed || results[0].RetryAfter != 500*time.Millisecond {
+		t.Errorf("after 500ms: allowed=%v RetryAfter=%v, want denied for 500ms", allowed, results[0].RetryAfter)
+	}
+
+	r.advance(500 * time.Millisecond)
+	allowed, results = eval(t, r, false, rule)
+	if !allowed {
+		t.Fatal("denied after a token refilled")
+	}
+	if results[0].Remaining != 0 {
+		t.Errorf("Remaining = %d, want 0", results[0].Remaining)
+	}
+}
+
+func TestRedisSlidingWindowRetryAfterOldestEntries(t *testing.T) {
+	r := newTestRedis(t)
+	rule := Rule{Key: "test:sliding", Algorithm: "sliding_window", Limit: 3, Window: 10 * time.Second, Cost: 1}
+
+	// Requests at 0s, 2s and 4s fill the window
+	for i := 0; i < 3; i++ {
+		if allowed, _ := eval(t, r, false, rule); !allowed {
+			t.Fatalf("request %d denied within limit", i+1)
+		}
+		r.advance(2 * time.Second)
+	}
+	r.advance(-time.Second) // now at 5s
+
+	// One more slot opens when the 0s entry leaves, two when the 2s entry does
+	if allowed, results := eval(t, r, true, rule); allowed || results[0].RetryAfter != 5*time.Second {
+		t.Errorf("cost 1: allowed=%v RetryAfter=%v, want denied for 5s", allowed, results[0].RetryAfter)
+	}
+	rule2 := rule
+	rule2.Cost = 2
+	if allowed, results := eval(t, r, true, rule2); allowed || results[0].RetryAfter != 7*time.Second {
+		t.Errorf("cost 2: allowed=%v RetryAfter=%v, want denied for 7s", allowed, results[0].RetryAfter)
+	}
+	rule4 := rule
+	rule4.Cost = 4
+	if allowed, results := eval(t, r, true, rule4); allowed || results[0].RetryAfter != 10*time.Second {
+		t.Errorf("cost above limit: allowed=%v RetryAfter=%v, want denied for the window", allowed, results[0].RetryAfter)
+	}
+
+	r.advance(5 * time.Second) // now at 10s, the 0s entry is out
+	allowed, results := eval(t, r, false, rule)
+	if !allowed {
+		t.Fatal("denied after the oldest entry left the window")
+	}
+	if results[0].Remaining != 0 {
+		t.Errorf("Remaining = %d, want 0", results[0].Remaining)
+	}
+}
+
+func TestRedisFixedWindowTTLDoesNotExtend(t *testing.T) {
+	r := newTestRedis(t)
+	rule := Rule{Key: "test:fixed", Algorithm: "fixed_window", Limit: 2, Window: 10 * time.Second, Cost: 1}
+
+	if allowed, results := eval(t, r, false, rule); !allowed || results[0].ResetAfter != 10*time.Second {
+		t.Fatalf("first request: allowed=%v ResetAfter=%v, want allowed with a 10s window", allowed, results[0].ResetAfter)
+	}
+	r.advance(4 * time.Second)
+	if allowed, _ := eval(t, r, false, rule); !allowed {
+		t.Fatal("second request denied within limit")
+	}
+	if ttl := r.server.TTL(rule.Key); ttl != 6*time.Second {
+		t.Errorf("TTL = %v after a later hit, want the window's remaining 6s", ttl)
+	}
+
+	allowed, results := eval(t, r, false, rule)
+	if allowed || results[0].RetryAfter != 6*time.Second {
+		t.Errorf("over limit: allowed=%v RetryAfter=%v, want denied until the window ends in 6s", allowed, results[0].RetryAfter)
+	}
+
+	r.advance(6 * time.Second)
+	if allowed, results := eval(t, r, false, rule); !allowed || results[0].Remaining != 1 {
+		t.Errorf("next window: allowed=%v Remaining=%d, want allowed with 1 left", allowed, results[0].Remaining)
+	}
+}
+
+func TestRedisBatchIsAllOrNothing(t *testing.T) {
+	r := newTestRedis(t)
+	token := Rule{Key: "test:batch:token", Algorithm: "token_bucket", Limit: 5, Rate: 1, Cost: 1}
+	sliding := Rule{Key: "test:batch:sliding", Algorithm: "sliding_window", Limit: 5, Window: time.Minute, Cost: 1}
+	fixed := Rule{Key: "test:batch:fixed", Algorithm: "fixed_window", Limit: 1, Window: time.Minute, Cost: 1}
+
+	if allowed, _ := eval(t, r, false, fixed); !allowed {
+		t.Fatal("first request denied")
+	}
+
+	allowed, results := eval(t, r, false, token, sliding, fixed)
+	if allowed {
+		t.Fatal("batch allowed with one rule over its limit")
+	}
+	if !results[0].Allowed || !results[1].Allowed || results[2].Allowed {
+		t.Errorf("per-rule allowed = %v %v %v, want true true false", results[0].Allowed, results[1].Allowed, results[2].Allowed)
+	}
+	for _, key := range []string{token.Key, sliding.Key} {
+		if r.server.Exists(key) {
+			t.Errorf("%s was consumed by a denied batch", key)
+		}
+	}
+	if got, _ := r.server.Get(fixed.Key); got != "1" {
+		t.Errorf("%s = %q, want 1", fixed.Key, got)
+	}
+}
+
+func TestRedisPeekDoesNotConsume(t *testing.T) {
+	r := newTestRedis(t)
+	rules := []Rule{
+		{Key: "test:peek:token", Algorithm: "token_bucket", Limit: 1, Rate: 1, Cost: 1},
+		{Key: "test:peek:sliding", Algorithm: "sliding_window", Limit: 1, Window: time.Minute, Cost: 1},
+		{Key: "test:peek:fixed", Algorithm: "fixed_window", Limit: 1, Window: time.Minute, Cost: 1},
+	}
+
+	for i := 0; i < 3; i++ {
+		allowed, results := eval(t, r, true, rules...)
+		if !allowed {
+			t.Fatalf("peek %d denied", i+1)
+		}
+		for j, result := range results {
+			if result.Remaining != 1 {
+				t.Errorf("peek %d: rule %d Remaining = %d, want 1", i+1, j, result.Remaining)
+			}
+		}
+	}
+	if keys := r.server.Keys(); len(keys) != 0 {
+		t.Errorf("peek wrote keys %v", keys)
+	}
+
+	if allowed, _ := eval(t, r, false, rules...); !allowed {
+		t.Fatal("consume denied after peeks")
+	}
+	if allowed, _ := eval(t, r, true, rules...); allowed {
+		t.Error("peek allowed after the limit was consumed")
+	}
+}